@@ -0,0 +1,190 @@
+package pushover
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Audited operations.
+const (
+	OperationSendMessage          = "send_message"
+	OperationSendGlanceUpdate     = "send_glance_update"
+	OperationCancelEmergency      = "cancel_emergency"
+	OperationCancelEmergencyByTag = "cancel_emergency_by_tag"
+	OperationGetReceiptDetails    = "get_receipt_details"
+	OperationValidateRecipient    = "validate_recipient"
+	OperationCreateGroup          = "create_group"
+	OperationGetGroup             = "get_group"
+	OperationAddUserToGroup       = "add_user_to_group"
+	OperationRemoveUserFromGroup  = "remove_user_from_group"
+	OperationDisableUserInGroup   = "disable_user_in_group"
+	OperationEnableUserInGroup    = "enable_user_in_group"
+	OperationRenameGroup          = "rename_group"
+	OperationGetLimits            = "get_limits"
+	OperationGetSounds            = "get_sounds"
+	OperationRegisterDevice       = "register_device"
+	OperationLogin                = "login"
+	OperationUnknown              = "unknown"
+)
+
+// auditOperation matches the requests of an audited operation.
+type auditOperation struct {
+	method    string
+	path      *regexp.Regexp
+	operation string
+}
+
+// auditOperations are matched in order against the end of the escaped path
+// of the requests.
+var auditOperations []auditOperation
+
+func init() {
+	for _, o := range []struct{ method, path, operation string }{
+		{"POST", `/messages\.json$`, OperationSendMessage},
+		{"POST", `/glances\.json$`, OperationSendGlanceUpdate},
+		{"POST", `/receipts/cancel_by_tag/[^/]+\.json$`, OperationCancelEmergencyByTag},
+		{"POST", `/receipts/[^/]+/cancel\.json$`, OperationCancelEmergency},
+		{"GET", `/receipts/[^/]+\.json$`, OperationGetReceiptDetails},
+		{"POST", `/users/validate\.json$`, OperationValidateRecipient},
+		{"POST", `/groups\.json$`, OperationCreateGroup},
+		{"GET", `/groups/[^/]+\.json$`, OperationGetGroup},
+		{"POST", `/groups/[^/]+/add_user\.json$`, OperationAddUserToGroup},
+		{"POST", `/groups/[^/]+/remove_user\.json$`, OperationRemoveUserFromGroup},
+		{"POST", `/groups/[^/]+/disable_user\.json$`, OperationDisableUserInGroup},
+		{"POST", `/groups/[^/]+/enable_user\.json$`, OperationEnableUserInGroup},
+		{"POST", `/groups/[^/]+/rename\.json$`, OperationRenameGroup},
+		{"GET", `/apps/limits\.json$`, OperationGetLimits},
+		{"GET", `/sounds\.json$`, OperationGetSounds},
+		{"POST", `/devices\.json$`, OperationRegisterDevice},
+		{"POST", `/users/login\.json$`, OperationLogin},
+	} {
+		auditOperations = append(auditOperations, auditOperation{
+			method:    o.method,
+			path:      regexp.MustCompile(o.path),
+			operation: o.operation,
+		})
+	}
+}
+
+// requestOperation returns the audited operation of a request.
+func requestOperation(req *http.Request) string {
+	path := req.URL.EscapedPath()
+	for _, o := range auditOperations {
+		if req.Method == o.method && o.path.MatchString(path) {
+			return o.operation
+		}
+	}
+
+	return OperationUnknown
+}
+
+// AuditEvent describes a request made to the API. The recipient token is
+// redacted, only its first characters are kept.
+type AuditEvent struct {
+	Time          time.Time
+	Operation     string
+	Recipient     string
+	MessageLength int
+	Priority      Priority
+	Attachment    bool
+	RequestID     string
+	Err           error
+}
+
+// AuditSink receives the audit events, it must be safe for concurrent use.
+type AuditSink interface {
+	Audit(event *AuditEvent)
+}
+
+// AuditSinkFunc is an adapter to use a function as an AuditSink.
+type AuditSinkFunc func(event *AuditEvent)
+
+// Audit calls f(event).
+func (f AuditSinkFunc) Audit(event *AuditEvent) {
+	f(event)
+}
+
+// WithAuditSink sets the audit sink of the app, see SetAuditSink. Unlike
+// SetAuditSink it can be used with Login and NewClient.
+func WithAuditSink(sink AuditSink) Option {
+	return func(p *Pushover) {
+		p.auditSink = sink
+	}
+}
+
+// SetAuditSink sets the sink receiving an audit event for every request made
+// to the API, the retries included. The operations failing before a request
+// is made, e.g. on an invalid message, are not audited. No event is emitted by
+// default.
+func (p *Pushover) SetAuditSink(sink AuditSink) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.auditSink = sink
+}
+
+// auditDetailsKey is the context key of the auditDetails of a request.
+type auditDetailsKey struct{}
+
+// auditDetails are the details of a request added to its audit event.
+type auditDetails struct {
+	message   *Message
+	recipient *Recipient
+}
+
+// withAuditDetails returns a context adding the message and the recipient,
+// both optional, to the audit events of the requests made with it.
+func withAuditDetails(ctx context.Context, message *Message, recipient *Recipient) context.Context {
+	return context.WithValue(ctx, auditDetailsKey{}, &auditDetails{
+		message:   message,
+		recipient: recipient,
+	})
+}
+
+// auditRequest sends the audit event of a request to the audit sink if any.
+func (p *Pushover) auditRequest(req *http.Request, resType interface{}, err error) {
+	p.mu.Lock()
+	sink := p.auditSink
+	p.mu.Unlock()
+
+	if sink == nil {
+		return
+	}
+
+	event := &AuditEvent{
+		Time:      p.now(),
+		Operation: requestOperation(req),
+		Err:       err,
+	}
+
+	if details, ok := req.Context().Value(auditDetailsKey{}).(*auditDetails); ok {
+		if details.recipient != nil {
+			event.Recipient = redactToken(details.recipient.token)
+		}
+
+		if m := details.message; m != nil {
+			event.MessageLength = utf8.RuneCountInString(m.Message)
+			event.Priority = m.Priority
+			event.Attachment = m.attachment != nil
+		}
+	}
+
+	if res, ok := resType.(responder); ok {
+		event.RequestID = res.response().ID
+	}
+
+	sink.Audit(event)
+}
+
+// redactToken hides all but the first characters of a token.
+func redactToken(token string) string {
+	const visible = 4
+	if len(token) <= visible {
+		return strings.Repeat("*", len(token))
+	}
+
+	return token[:visible] + strings.Repeat("*", len(token)-visible)
+}
@@ -0,0 +1,156 @@
+package pushover
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestAuditEvents tests an audit event is emitted for every operation
+func TestAuditEvents(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	var events []*AuditEvent
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+	app.SetAuditSink(AuditSinkFunc(func(event *AuditEvent) {
+		events = append(events, event)
+	}))
+
+	message := NewMessage("Test message")
+	message.Priority = PriorityHigh
+	if err := message.AddAttachment(strings.NewReader("data")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := app.SendMessage(message, fakeRecipient); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Nothing is sent to the API
+	if _, err := app.SendMessage(NewMessage(""), fakeRecipient); err != ErrMessageEmpty {
+		t.Fatalf("expected %v, got %v", ErrMessageEmpty, err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+
+	event := events[0]
+	if event.Operation != OperationSendMessage {
+		t.Errorf("expected operation %q, got %q", OperationSendMessage, event.Operation)
+	}
+	if event.Recipient != "gzne**************************" {
+		t.Errorf("expected a redacted recipient, got %q", event.Recipient)
+	}
+	if event.MessageLength != 12 || event.Priority != PriorityHigh || !event.Attachment {
+		t.Errorf("unexpected message details %+v", event)
+	}
+	if event.RequestID != "e460545a8b333d0da2f3602aff3133d6" || event.Err != nil {
+		t.Errorf("unexpected result %+v", event)
+	}
+}
+
+// TestAuditOperations tests the requests of every endpoint are audited with
+// their operation
+func TestAuditOperations(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		if strings.HasSuffix(r.URL.Path, "/rename.json") {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, `{"status":0,"request":"e460545a8b333d0da2f3602aff3133d6","errors":["name is invalid"]}`)
+			return
+		}
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	var events []*AuditEvent
+	sink := AuditSinkFunc(func(event *AuditEvent) {
+		events = append(events, event)
+	})
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL), WithAuditSink(sink))
+	group := "gznej3rKEVAvPUxu9vvNnqpmZpokzF"
+	user := "uQiRzpo4DXghDmr9QzzfQu27cmVRsG"
+
+	app.SendGlanceUpdate(&Glance{Title: String("Title")}, fakeRecipient)
+	app.CancelEmergencyNotification("r1")
+	app.CancelEmergencyNotificationByTag("incident/42")
+	app.GetReceiptDetails("r1")
+	app.GetRecipientDetails(fakeRecipient)
+	app.CreateGroup("Group")
+	app.GetGroup(group)
+	app.AddUserToGroup(group, user, "", "")
+	app.RemoveUserFromGroup(group, user)
+	app.DisableUserInGroup(group, user, "")
+	app.EnableUserInGroup(group, user, "")
+	app.RenameGroup(group, "Group")
+	app.GetLimits()
+	app.GetSounds()
+	NewClient("secret", WithEndpoint(ts.URL), WithAuditSink(sink)).RegisterDevice("phone")
+	Login("user@example.com", "password", "", WithEndpoint(ts.URL), WithAuditSink(sink))
+
+	expected := []string{
+		OperationSendGlanceUpdate,
+		OperationCancelEmergency,
+		OperationCancelEmergencyByTag,
+		OperationGetReceiptDetails,
+		OperationValidateRecipient,
+		OperationCreateGroup,
+		OperationGetGroup,
+		OperationAddUserToGroup,
+		OperationRemoveUserFromGroup,
+		OperationDisableUserInGroup,
+		OperationEnableUserInGroup,
+		OperationRenameGroup,
+		OperationGetLimits,
+		OperationGetSounds,
+		OperationRegisterDevice,
+		OperationLogin,
+	}
+
+	var got []string
+	for _, event := range events {
+		got = append(got, event.Operation)
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("unexpected operations\nexpected: %v\ngot: %v", expected, got)
+	}
+
+	if events[0].Recipient != "gzne**************************" {
+		t.Errorf("expected a redacted recipient, got %q", events[0].Recipient)
+	}
+
+	if rename := events[11]; rename.Err == nil || rename.RequestID != "e460545a8b333d0da2f3602aff3133d6" {
+		t.Errorf("expected the error of the request, got %+v", rename)
+	}
+}
+
+// TestRedactToken tests the redaction of the tokens
+func TestRedactToken(t *testing.T) {
+	tt := []struct {
+		token    string
+		expected string
+	}{
+		{"", ""},
+		{"abc", "***"},
+		{"abcdefgh", "abcd****"},
+	}
+
+	for _, tc := range tt {
+		if got := redactToken(tc.token); got != tc.expected {
+			t.Errorf("expected %q, got %q", tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package pushover
+
+import "time"
+
+// MessageBuilder builds a message using chained calls:
+//
+//	message, err := NewMessageBuilder("Disk full").
+//		Title("Alert").
+//		Priority(PriorityHigh).
+//		Sound(SoundSiren).
+//		Device("phone").
+//		Build()
+//
+// The methods can't be defined on Message since their names are the ones of
+// the fields.
+type MessageBuilder struct {
+	message Message
+}
+
+// NewMessageBuilder returns a new builder of a message with the given text.
+func NewMessageBuilder(message string) *MessageBuilder {
+	return &MessageBuilder{message: Message{Message: message}}
+}
+
+// Title sets the title of the message.
+func (b *MessageBuilder) Title(title string) *MessageBuilder {
+	b.message.Title = title
+	return b
+}
+
+// Priority sets the priority of the message.
+func (b *MessageBuilder) Priority(priority Priority) *MessageBuilder {
+	b.message.Priority = priority
+	return b
+}
+
+// Emergency sets the emergency priority along with its retry and expire
+// parameters.
+func (b *MessageBuilder) Emergency(retry, expire time.Duration) *MessageBuilder {
+	b.message.Priority = PriorityEmergency
+	b.message.Retry = retry
+	b.message.Expire = expire
+	return b
+}
+
+// Sound sets the sound of the message.
+func (b *MessageBuilder) Sound(sound string) *MessageBuilder {
+	b.message.Sound = sound
+	return b
+}
+
+// CustomSound sets a sound uploaded by the user as the sound of the message.
+func (b *MessageBuilder) CustomSound(sound string) *MessageBuilder {
+	b.message.Sound = sound
+	b.message.AllowCustomSound = true
+	return b
+}
+
+// Device sets the device name of the message.
+func (b *MessageBuilder) Device(device string) *MessageBuilder {
+	b.message.DeviceName = device
+	return b
+}
+
+// URL sets the supplementary URL of the message and its title.
+func (b *MessageBuilder) URL(url, title string) *MessageBuilder {
+	b.message.URL = url
+	b.message.URLTitle = title
+	return b
+}
+
+// Timestamp sets the timestamp of the message.
+func (b *MessageBuilder) Timestamp(t time.Time) *MessageBuilder {
+	b.message.SetTimestamp(t)
+	return b
+}
+
+// Build validates and returns a new message, the builder can be reused.
+func (b *MessageBuilder) Build() (*Message, error) {
+	message := b.message
+	if err := message.validate(); err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
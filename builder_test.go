@@ -0,0 +1,56 @@
+package pushover
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestMessageBuilder tests the messages built with chained calls
+func TestMessageBuilder(t *testing.T) {
+	got, err := NewMessageBuilder("Disk full").
+		Title("Alert").
+		Priority(PriorityHigh).
+		Sound(SoundSiren).
+		Device("phone").
+		URL("https://example.com", "Dashboard").
+		Build()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := &Message{
+		Message:    "Disk full",
+		Title:      "Alert",
+		Priority:   PriorityHigh,
+		Sound:      SoundSiren,
+		DeviceName: "phone",
+		URL:        "https://example.com",
+		URLTitle:   "Dashboard",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	// The messages are validated
+	if _, err := NewMessageBuilder("Hello").Emergency(10*time.Second, time.Hour).Build(); err != ErrEmergencyRetryTooShort {
+		t.Errorf("expected %v, got %v", ErrEmergencyRetryTooShort, err)
+	}
+}
+
+// TestMessageBuilderCustomSound tests the sounds uploaded by the users can be
+// used by the built messages
+func TestMessageBuilderCustomSound(t *testing.T) {
+	if _, err := NewMessageBuilder("Hello").Sound("my_sound").Build(); err != ErrInvalidSound {
+		t.Fatalf("expected %v, got %v", ErrInvalidSound, err)
+	}
+
+	got, err := NewMessageBuilder("Hello").CustomSound("my_sound").Build()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if got.Sound != "my_sound" || !got.AllowCustomSound {
+		t.Errorf("expected the custom sound to be allowed, got %+v", got)
+	}
+}
@@ -0,0 +1,102 @@
+package pushover
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Query parameters added to a signed callback URL.
+const (
+	callbackIDParam        = "pushover_id"
+	callbackSignatureParam = "pushover_signature"
+)
+
+// CallbackPayload represents the data posted by pushover to the callback URL
+// of an emergency notification once it has been acknowledged.
+type CallbackPayload struct {
+	Receipt              string
+	Acknowledged         bool
+	AcknowledgedAt       *time.Time
+	AcknowledgedBy       string
+	AcknowledgedByDevice string
+}
+
+// SignCallbackURL returns the callback URL with the id and its HMAC signature
+// added to the query. The receipt is only known once the message has been
+// sent so the signature can't cover it, the id is chosen by the caller to
+// identify the message instead, e.g. the id of the alert.
+//
+// The signature only proves that the URL was signed with the secret, a
+// callback request captured by a third party can be replayed with the same id.
+// Use a unique id per message and handle the acknowledgement of an id once.
+func SignCallbackURL(callbackURL, id string, secret []byte) (string, error) {
+	if id == "" {
+		return "", ErrInvalidCallbackID
+	}
+
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return "", err
+	}
+
+	q := u.Query()
+	q.Set(callbackIDParam, id)
+	q.Set(callbackSignatureParam, callbackSignature(id, secret))
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// VerifyCallback checks that a callback request was made on a URL signed by
+// SignCallbackURL for the expected id and returns the payload sent by
+// pushover. See SignCallbackURL for the replay limits.
+func VerifyCallback(r *http.Request, id string, secret []byte) (*CallbackPayload, error) {
+	q := r.URL.Query()
+	signedID := q.Get(callbackIDParam)
+	signature := q.Get(callbackSignatureParam)
+	if id == "" || signedID != id || signature == "" {
+		return nil, ErrInvalidCallbackSignature
+	}
+
+	expected := callbackSignature(signedID, secret)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, ErrInvalidCallbackSignature
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	payload := &CallbackPayload{
+		Receipt:              r.PostForm.Get("receipt"),
+		Acknowledged:         r.PostForm.Get("acknowledged") == "1",
+		AcknowledgedBy:       r.PostForm.Get("acknowledged_by"),
+		AcknowledgedByDevice: r.PostForm.Get("acknowledged_by_device"),
+	}
+
+	if v := r.PostForm.Get("acknowledged_at"); v != "" {
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		if i > 0 {
+			acknowledgedAt := time.Unix(i, 0)
+			payload.AcknowledgedAt = &acknowledgedAt
+		}
+	}
+
+	return payload, nil
+}
+
+// callbackSignature returns the hex encoded HMAC of the id.
+func callbackSignature(id string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,115 @@
+package pushover
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestVerifyCallback tests the signature of the callback URLs
+func TestVerifyCallback(t *testing.T) {
+	secret := []byte("secret")
+	signed, err := SignCallbackURL("http://yourapp.com/callback?id=42", "alert-42", secret)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if u.Query().Get("id") != "42" {
+		t.Fatalf("the original query should be kept")
+	}
+
+	tampered := strings.Replace(signed, "alert-42", "alert-43", 1)
+
+	form := url.Values{
+		"receipt":                {"rLqVuqTRh62UzxtmqiaLzQmVcPgiCy"},
+		"acknowledged":           {"1"},
+		"acknowledged_at":        {"1424305421"},
+		"acknowledged_by":        {"uYWtrQ4scpDU38cz5X5pvxNvu7b15"},
+		"acknowledged_by_device": {"iphone"},
+	}
+
+	acknowledgedAt := time.Unix(1424305421, 0)
+
+	tt := []struct {
+		name        string
+		url         string
+		id          string
+		secret      []byte
+		expected    *CallbackPayload
+		expectedErr error
+	}{
+		{
+			name:   "valid signature",
+			url:    signed,
+			id:     "alert-42",
+			secret: secret,
+			expected: &CallbackPayload{
+				Receipt:              "rLqVuqTRh62UzxtmqiaLzQmVcPgiCy",
+				Acknowledged:         true,
+				AcknowledgedAt:       &acknowledgedAt,
+				AcknowledgedBy:       "uYWtrQ4scpDU38cz5X5pvxNvu7b15",
+				AcknowledgedByDevice: "iphone",
+			},
+		},
+		{
+			name:        "invalid secret",
+			url:         signed,
+			id:          "alert-42",
+			secret:      []byte("another secret"),
+			expectedErr: ErrInvalidCallbackSignature,
+		},
+		{
+			name:        "tampered id",
+			url:         tampered,
+			id:          "alert-43",
+			secret:      secret,
+			expectedErr: ErrInvalidCallbackSignature,
+		},
+		{
+			name:        "URL signed for another id",
+			url:         signed,
+			id:          "alert-43",
+			secret:      secret,
+			expectedErr: ErrInvalidCallbackSignature,
+		},
+		{
+			name:        "unsigned URL",
+			url:         "http://yourapp.com/callback",
+			id:          "alert-42",
+			secret:      secret,
+			expectedErr: ErrInvalidCallbackSignature,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", tc.url, strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			got, err := VerifyCallback(r, tc.id, tc.secret)
+			if err != tc.expectedErr {
+				t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("unexpected payload\nexpected: %+v\ngot: %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestSignCallbackURLEmptyID tests the callback URLs can't be signed without
+// an id
+func TestSignCallbackURLEmptyID(t *testing.T) {
+	if _, err := SignCallbackURL("http://yourapp.com/callback", "", []byte("secret")); err != ErrInvalidCallbackID {
+		t.Errorf("expected %v, got %v", ErrInvalidCallbackID, err)
+	}
+}
@@ -0,0 +1,90 @@
+package pushover
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Client represents a desktop client of the Open Client API, it's
+// authenticated with the secret of a user instead of an app token.
+type Client struct {
+	secret string
+	app    *Pushover
+}
+
+// NewClient returns a client authenticated with the secret of a user, see
+// Login. The options of the apps such as WithHTTPClient or WithEndpoint can be
+// used.
+func NewClient(secret string, opts ...Option) *Client {
+	return &Client{secret: secret, app: New("", opts...)}
+}
+
+// loginResponse represents the response of a login.
+type loginResponse struct {
+	Response
+	Secret string `json:"secret"`
+}
+
+// Login logs in a user with its email and password to get the secret used by
+// NewClient. The two-factor authentication code is only required if enabled
+// by the user, ErrTwoFactorRequired is returned if it's missing. The options
+// of the apps such as WithEndpoint can be used.
+func Login(email, password, twofa string, opts ...Option) (string, error) {
+	app := New("", opts...)
+
+	params := map[string]string{"email": email, "password": password}
+	if twofa != "" {
+		params["twofa"] = twofa
+	}
+
+	endpoint := fmt.Sprintf("%s/users/login.json", app.Endpoint())
+	req, err := newURLEncodedRequest("POST", endpoint, params)
+	if err != nil {
+		return "", err
+	}
+
+	response := &loginResponse{}
+	if err := app.do(req, response, false); err != nil {
+		// The API asks for the code with a precondition failed status
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusPreconditionFailed {
+			return "", ErrTwoFactorRequired
+		}
+		return "", err
+	}
+
+	return response.Secret, nil
+}
+
+// deviceResponse represents the response of a device registration.
+type deviceResponse struct {
+	Response
+	ID string `json:"id"`
+}
+
+// RegisterDevice registers a new desktop device for the user and returns its
+// ID, which is used to download the messages.
+func (c *Client) RegisterDevice(name string) (string, error) {
+	if c.secret == "" {
+		return "", ErrEmptySecret
+	}
+
+	if !deviceNameRegexp.MatchString(name) {
+		return "", ErrInvalidDeviceName
+	}
+
+	endpoint := fmt.Sprintf("%s/devices.json", c.app.Endpoint())
+	req, err := newURLEncodedRequest("POST", endpoint,
+		map[string]string{"secret": c.secret, "name": name, "os": "O"})
+	if err != nil {
+		return "", err
+	}
+
+	response := &deviceResponse{}
+	if err := c.app.do(req, response, false); err != nil {
+		return "", err
+	}
+
+	return response.ID, nil
+}
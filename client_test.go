@@ -0,0 +1,117 @@
+package pushover
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRegisterDevice tests the registration of a desktop device
+func TestRegisterDevice(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/devices.json" || r.FormValue("secret") != "secret" || r.FormValue("os") != "O" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, `{"status":0,"request":"e460545a8b333d0da2f3602aff3133d6","errors":["secret is invalid"]}`)
+			return
+		}
+
+		if r.FormValue("name") == "taken" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, `{"status":0,"request":"e460545a8b333d0da2f3602aff3133d6","errors":["name has already been taken"]}`)
+			return
+		}
+		fmt.Fprintln(w, `{"status":1,"id":"dfbr3t5ueb6nl1x2rd0s","request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	tt := []struct {
+		name        string
+		secret      string
+		device      string
+		expected    string
+		expectedErr error
+	}{
+		{"registered", "secret", "desktop", "dfbr3t5ueb6nl1x2rd0s", nil},
+		{"name taken", "secret", "taken", "", &APIError{}},
+		{"invalid name", "secret", "my^desktop", "", ErrInvalidDeviceName},
+		{"empty secret", "", "desktop", "", ErrEmptySecret},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewClient(tc.secret, WithEndpoint(ts.URL))
+			got, err := client.RegisterDevice(tc.device)
+
+			var apiErr *APIError
+			switch tc.expectedErr.(type) {
+			case *APIError:
+				if !errors.As(err, &apiErr) {
+					t.Fatalf("expected an APIError, got %v", err)
+				}
+			default:
+				if err != tc.expectedErr {
+					t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+				}
+			}
+
+			if got != tc.expected {
+				t.Errorf("expected the device ID %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestLogin tests the secret of the user is returned
+func TestLogin(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/users/login.json" || r.FormValue("email") != "user@example.com" || r.FormValue("password") != "password" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, `{"status":0,"request":"e460545a8b333d0da2f3602aff3133d6","errors":["invalid email and/or password"]}`)
+			return
+		}
+
+		if r.FormValue("twofa") != "123456" {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			fmt.Fprintln(w, `{"status":0,"request":"e460545a8b333d0da2f3602aff3133d6","errors":["two-factor authentication code required"]}`)
+			return
+		}
+		fmt.Fprintln(w, `{"status":1,"id":"uQiRzpo4DXghDmr9QzzfQu27cmVRsG","secret":"dpWnWmaUYEYYBcWkZdCyfLuz9eEAMCumzzQeaBbTvZDZkbUHEoqcg1","request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	tt := []struct {
+		name        string
+		password    string
+		twofa       string
+		expected    string
+		expectedErr error
+	}{
+		{"logged in", "password", "123456", "dpWnWmaUYEYYBcWkZdCyfLuz9eEAMCumzzQeaBbTvZDZkbUHEoqcg1", nil},
+		{"bad credentials", "wrong", "123456", "", &APIError{}},
+		{"two-factor required", "password", "", "", ErrTwoFactorRequired},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Login("user@example.com", tc.password, tc.twofa, WithEndpoint(ts.URL))
+
+			var apiErr *APIError
+			switch tc.expectedErr.(type) {
+			case *APIError:
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+					t.Fatalf("expected an APIError, got %v", err)
+				}
+			default:
+				if err != tc.expectedErr {
+					t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+				}
+			}
+
+			if got != tc.expected {
+				t.Errorf("expected the secret %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
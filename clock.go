@@ -0,0 +1,78 @@
+package pushover
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of time of an app, it can be replaced to test the time
+// based features such as the quota checks, the global rate, the escalations
+// and the receipt polling.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current
+	// time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the Clock using the time package.
+type realClock struct{}
+
+// Now returns the current time.
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for the duration to elapse.
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// afterFunc calls f in its own goroutine once the duration has elapsed on the
+// clock, unless the returned stop function is called before.
+func afterFunc(clock Clock, d time.Duration, f func()) (stop func()) {
+	stopped := make(chan struct{})
+	after := clock.After(d)
+	go func() {
+		select {
+		case <-after:
+			f()
+		case <-stopped:
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(stopped) })
+	}
+}
+
+// SetClock sets the source of time of the app, the real clock is used by
+// default.
+func (p *Pushover) SetClock(clock Clock) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clock = clock
+}
+
+// getClock returns the source of time of the app.
+func (p *Pushover) getClock() Clock {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.clockLocked()
+}
+
+// clockLocked returns the source of time of the app, p.mu must be held.
+func (p *Pushover) clockLocked() Clock {
+	if p.clock == nil {
+		return realClock{}
+	}
+
+	return p.clock
+}
+
+// now returns the current time according to the clock of the app.
+func (p *Pushover) now() time.Time {
+	return p.getClock().Now()
+}
@@ -0,0 +1,142 @@
+package pushover
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock whose time only moves when After is called.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+// manualClock is a Clock whose time only moves when Advance is called, unlike
+// fakeClock it can be used concurrently.
+type manualClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []manualClockWaiter
+}
+
+type manualClockWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+
+	c.waiters = append(c.waiters, manualClockWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the time forward and fires the elapsed waiters.
+func (c *manualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = waiters
+}
+
+// TestClock tests the clock of the app is used for the quota checks
+func TestClock(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "0")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+	clock := &fakeClock{now: time.Unix(1393653600, 0).Add(-time.Hour)}
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+	app.SetClock(clock)
+
+	if _, err := app.SendMessage(NewMessage("Hello"), fakeRecipient); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	ctx := context.Background()
+	if err := app.PreflightSend(ctx, NewMessage("Hello"), fakeRecipient); err != ErrQuotaExhausted {
+		t.Fatalf("expected %v, got %v", ErrQuotaExhausted, err)
+	}
+
+	// The quota is reset
+	<-clock.After(time.Hour)
+	if err := app.PreflightSend(ctx, NewMessage("Hello"), fakeRecipient); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+// TestRateLimitWait tests the messages wait for the reset of the quota
+func TestRateLimitWait(t *testing.T) {
+	reset := time.Unix(1393653600, 0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "0")
+		w.Header().Set("X-Limit-App-Reset", fmt.Sprintf("%d", reset.Unix()))
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	clock := &fakeClock{now: reset.Add(-time.Hour)}
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL), WithRateLimitWait(true))
+	app.SetClock(clock)
+
+	if _, _, ok := app.RemainingMessages(); ok {
+		t.Fatal("expected no limits before the first message")
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := app.SendMessage(NewMessage("Hello"), fakeRecipient); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	// The second message waited for the reset
+	if !clock.now.Equal(reset) {
+		t.Errorf("expected the clock to be at %s, got %s", reset, clock.now)
+	}
+
+	remaining, nextReset, ok := app.RemainingMessages()
+	if !ok || remaining != 0 || !nextReset.Equal(reset) {
+		t.Errorf("unexpected remaining messages %d, %s, %t", remaining, nextReset, ok)
+	}
+}
@@ -0,0 +1,181 @@
+package pushover
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// glanceCoalescer collapses the glance updates sent to the same recipient and
+// device, at most one update is sent per interval.
+type glanceCoalescer struct {
+	interval time.Duration
+	clock    func() Clock
+	send     func(*Glance, *Recipient) (*Response, error)
+
+	mu       sync.Mutex
+	windows  map[string]*glanceWindow
+	inflight inflight
+}
+
+// glanceWindow holds the state of the updates of a recipient and device.
+type glanceWindow struct {
+	sentAt    time.Time
+	pending   *Glance
+	recipient *Recipient
+	stopTimer func()
+}
+
+func newGlanceCoalescer(interval time.Duration, clock func() Clock, send func(*Glance, *Recipient) (*Response, error)) *glanceCoalescer {
+	return &glanceCoalescer{
+		interval: interval,
+		clock:    clock,
+		send:     send,
+		windows:  map[string]*glanceWindow{},
+	}
+}
+
+// submit sends the glance right away if nothing was sent during the current
+// interval, otherwise the glance replaces the pending one which will be sent
+// at the end of the interval.
+func (c *glanceCoalescer) submit(glance *Glance, recipient *Recipient) (*Response, error) {
+	key := recipient.token + "/" + glance.DeviceName
+	clock := c.clock()
+	now := clock.Now()
+
+	c.mu.Lock()
+	w, ok := c.windows[key]
+	if !ok {
+		w = &glanceWindow{}
+		c.windows[key] = w
+	}
+
+	if w.stopTimer == nil && now.Sub(w.sentAt) >= c.interval {
+		w.sentAt = now
+		c.mu.Unlock()
+		return c.send(glance, recipient)
+	}
+
+	g := *glance
+	w.pending = &g
+	w.recipient = recipient
+	if w.stopTimer == nil {
+		w.stopTimer = afterFunc(clock, w.sentAt.Add(c.interval).Sub(now), func() {
+			c.sendPending(key)
+		})
+	}
+	c.mu.Unlock()
+
+	return &Response{Coalesced: true}, nil
+}
+
+// sendPending sends the pending glance of a window, the errors are dropped
+// since nobody is waiting for them.
+func (c *glanceCoalescer) sendPending(key string) {
+	now := c.clock().Now()
+
+	c.mu.Lock()
+	w := c.windows[key]
+	glance, recipient := w.pending, w.recipient
+	w.pending, w.recipient, w.stopTimer = nil, nil, nil
+	w.sentAt = now
+	if glance != nil {
+		c.inflight.add()
+	}
+	c.mu.Unlock()
+
+	if glance != nil {
+		c.send(glance, recipient)
+		c.inflight.done()
+	}
+}
+
+// flush sends all the pending glances right away and waits for the delayed
+// sends in progress. The first error is returned.
+func (c *glanceCoalescer) flush(ctx context.Context) error {
+	type pendingGlance struct {
+		glance    *Glance
+		recipient *Recipient
+	}
+
+	var pending []pendingGlance
+	now := c.clock().Now()
+	c.mu.Lock()
+	for _, w := range c.windows {
+		if w.stopTimer != nil {
+			w.stopTimer()
+		}
+
+		if w.pending != nil {
+			pending = append(pending, pendingGlance{w.pending, w.recipient})
+			w.sentAt = now
+		}
+		w.pending, w.recipient, w.stopTimer = nil, nil, nil
+	}
+	c.mu.Unlock()
+
+	var firstErr error
+	for _, p := range pending {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if _, err := c.send(p.glance, p.recipient); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if err := c.inflight.wait(ctx); err != nil {
+		return err
+	}
+
+	return firstErr
+}
+
+// inflight counts the operations in progress. Unlike a sync.WaitGroup,
+// operations can be started while waiting.
+type inflight struct {
+	mu    sync.Mutex
+	count int
+	idle  chan struct{}
+}
+
+// add records the start of an operation.
+func (f *inflight) add() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.count == 0 {
+		f.idle = make(chan struct{})
+	}
+	f.count++
+}
+
+// done records the end of an operation.
+func (f *inflight) done() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.count--
+	if f.count == 0 {
+		close(f.idle)
+	}
+}
+
+// wait waits until no operation is in progress or the context is done.
+func (f *inflight) wait(ctx context.Context) error {
+	f.mu.Lock()
+	if f.count == 0 {
+		f.mu.Unlock()
+		return nil
+	}
+	idle := f.idle
+	f.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,78 @@
+// Package debug provides helpers to debug the requests made to the Pushover
+// API.
+package debug
+
+import (
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"regexp"
+)
+
+// Regexps matching the tokens and the credentials in url encoded, multipart
+// and JSON bodies.
+var (
+	urlEncodedTokenRegexp = regexp.MustCompile(`((?:^|[?&\s])(?:token|user|secret|password|twofa)=)[^&\s]*`)
+	multipartTokenRegexp  = regexp.MustCompile(`(name="(?:token|user|secret|password|twofa)"\r\n\r\n)[^\r\n]*`)
+	jsonTokenRegexp       = regexp.MustCompile(`("(?:token|user|secret|password|twofa)"\s*:\s*)"[^"]*"`)
+)
+
+// Transport is an http.RoundTripper logging the full requests sent to the API
+// and the responses received. The tokens are redacted from the logs so the
+// output can be attached to a bug report.
+//
+//	client := &http.Client{Transport: &debug.Transport{}}
+//	app := pushover.New(token, pushover.WithHTTPClient(client))
+type Transport struct {
+	// Transport is the underlying RoundTripper, http.DefaultTransport is
+	// used if nil.
+	Transport http.RoundTripper
+	// Logger is used to write the logs, the standard logger is used if nil.
+	Logger *log.Logger
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	dump, err := httputil.DumpRequestOut(req, true)
+	if err != nil {
+		return nil, err
+	}
+	t.printf("pushover: request:\n%s", Redact(dump))
+
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.printf("pushover: request failed: %v", err)
+		return nil, err
+	}
+
+	dump, err = httputil.DumpResponse(resp, true)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	t.printf("pushover: response:\n%s", Redact(dump))
+
+	return resp, nil
+}
+
+func (t *Transport) printf(format string, v ...interface{}) {
+	if t.Logger == nil {
+		log.Printf(format, v...)
+		return
+	}
+
+	t.Logger.Printf(format, v...)
+}
+
+// Redact returns a copy of the dumped request or response with the values of
+// the token, user, secret, password and twofa parameters replaced.
+func Redact(dump []byte) []byte {
+	ret := urlEncodedTokenRegexp.ReplaceAll(dump, []byte("${1}REDACTED"))
+	ret = multipartTokenRegexp.ReplaceAll(ret, []byte("${1}REDACTED"))
+	return jsonTokenRegexp.ReplaceAll(ret, []byte(`${1}"REDACTED"`))
+}
@@ -0,0 +1,108 @@
+package debug
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// TestTransport tests the requests and responses are logged with the tokens
+// redacted
+func TestTransport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	logs := &bytes.Buffer{}
+	client := &http.Client{
+		Transport: &Transport{Logger: log.New(logs, "", 0)},
+	}
+
+	params := url.Values{
+		"token":   {"uQiRzpo4DXghDmr9QzzfQu27cmVRsG"},
+		"user":    {"gznej3rKEVAvPUxu9vvNnqpmZpokzF"},
+		"message": {"Hello"},
+	}
+
+	resp, err := client.PostForm(ts.URL+"/messages.json", params)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !strings.Contains(string(body), "e460545a8b333d0da2f3602aff3133d6") {
+		t.Errorf("the response body should still be readable, got %q", body)
+	}
+
+	out := logs.String()
+	for _, secret := range []string{params.Get("token"), params.Get("user")} {
+		if strings.Contains(out, secret) {
+			t.Errorf("the tokens should be redacted:\n%s", out)
+		}
+	}
+
+	for _, expected := range []string{"message=Hello", "token=REDACTED", "user=REDACTED", "e460545a8b333d0da2f3602aff3133d6"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q in the logs:\n%s", expected, out)
+		}
+	}
+}
+
+// TestRedactMultipart tests the tokens are redacted from multipart bodies
+func TestRedactMultipart(t *testing.T) {
+	dump := "--boundary\r\nContent-Disposition: form-data; name=\"token\"\r\n\r\nuQiRzpo4DXghDmr9QzzfQu27cmVRsG\r\n--boundary--"
+	expected := "--boundary\r\nContent-Disposition: form-data; name=\"token\"\r\n\r\nREDACTED\r\n--boundary--"
+
+	if got := string(Redact([]byte(dump))); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+// TestTransportLogin tests the credentials and the secret of a login are
+// redacted
+func TestTransportLogin(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"status":1,"id":"uQiRzpo4DXghDmr9QzzfQu27cmVRsG","secret":"Cxw5wSbq7q8Cbj5KBxQYkQCmTrMDFo","request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	logs := &bytes.Buffer{}
+	client := &http.Client{
+		Transport: &Transport{Logger: log.New(logs, "", 0)},
+	}
+
+	params := url.Values{
+		"email":    {"user@example.com"},
+		"password": {"hunter2"},
+		"twofa":    {"123456"},
+	}
+
+	resp, err := client.PostForm(ts.URL+"/users/login.json", params)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	out := logs.String()
+	for _, secret := range []string{"hunter2", "123456", "Cxw5wSbq7q8Cbj5KBxQYkQCmTrMDFo"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("%q should be redacted:\n%s", secret, out)
+		}
+	}
+
+	if !strings.Contains(out, `"secret":"REDACTED"`) {
+		t.Errorf("expected the secret to be redacted in the logs:\n%s", out)
+	}
+}
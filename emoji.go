@@ -0,0 +1,67 @@
+package pushover
+
+import "regexp"
+
+var shortcodeRegexp = regexp.MustCompile(`:[a-z0-9_+\-]+:`)
+
+// emojiShortcodes maps the common shortcodes to their emoji.
+var emojiShortcodes = map[string]string{
+	":+1:":                         "\U0001F44D",
+	":-1:":                         "\U0001F44E",
+	":alarm_clock:":                "⏰",
+	":bell:":                       "\U0001F514",
+	":bomb:":                       "\U0001F4A3",
+	":boom:":                       "\U0001F4A5",
+	":bug:":                        "\U0001F41B",
+	":calendar:":                   "\U0001F4C6",
+	":chart_with_upwards_trend:":   "\U0001F4C8",
+	":chart_with_downwards_trend:": "\U0001F4C9",
+	":check:":                      "✔️",
+	":clock:":                      "\U0001F550",
+	":cloud:":                      "☁️",
+	":construction:":               "\U0001F6A7",
+	":email:":                      "\U0001F4E7",
+	":exclamation:":                "❗",
+	":fire:":                       "\U0001F525",
+	":floppy_disk:":                "\U0001F4BE",
+	":gear:":                       "⚙️",
+	":heart:":                      "❤️",
+	":hourglass:":                  "⌛",
+	":house:":                      "\U0001F3E0",
+	":information_source:":         "ℹ️",
+	":key:":                        "\U0001F511",
+	":lock:":                       "\U0001F512",
+	":mag:":                        "\U0001F50D",
+	":money_with_wings:":           "\U0001F4B8",
+	":moon:":                       "\U0001F319",
+	":no_entry:":                   "⛔",
+	":ok:":                         "\U0001F197",
+	":package:":                    "\U0001F4E6",
+	":question:":                   "❓",
+	":rocket:":                     "\U0001F680",
+	":rotating_light:":             "\U0001F6A8",
+	":skull:":                      "\U0001F480",
+	":smile:":                      "\U0001F604",
+	":snowflake:":                  "❄️",
+	":sos:":                        "\U0001F198",
+	":star:":                       "⭐",
+	":sunny:":                      "☀️",
+	":tada:":                       "\U0001F389",
+	":thumbsdown:":                 "\U0001F44E",
+	":thumbsup:":                   "\U0001F44D",
+	":warning:":                    "⚠️",
+	":white_check_mark:":           "✅",
+	":x:":                          "❌",
+	":zap:":                        "⚡",
+}
+
+// expandShortcodes replaces the known emoji shortcodes by their emoji, the
+// unknown shortcodes are left untouched.
+func expandShortcodes(s string) string {
+	return shortcodeRegexp.ReplaceAllStringFunc(s, func(code string) string {
+		if emoji, ok := emojiShortcodes[code]; ok {
+			return emoji
+		}
+		return code
+	})
+}
@@ -0,0 +1,54 @@
+package pushover
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestExpandShortcodes tests the expansion of the emoji shortcodes
+func TestExpandShortcodes(t *testing.T) {
+	tt := []struct {
+		input    string
+		expected string
+	}{
+		{"no shortcode", "no shortcode"},
+		{":fire: server down :fire:", "\U0001F525 server down \U0001F525"},
+		{"unknown :not_an_emoji:", "unknown :not_an_emoji:"},
+		{"time 12:30:00", "time 12:30:00"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			if got := expandShortcodes(tc.input); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestSendMessageEmojiShortcodes tests the shortcodes are expanded before
+// sending
+func TestSendMessageEmojiShortcodes(t *testing.T) {
+	var message, title string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		message = r.FormValue("message")
+		title = r.FormValue("title")
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+	app.SetEmojiShortcodes(true)
+
+	if _, err := app.SendMessage(NewMessageWithTitle("Disk full :warning:", ":fire: Alert"), fakeRecipient); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if message != "Disk full ⚠️" || title != "\U0001F525 Alert" {
+		t.Errorf("unexpected message %q and title %q", message, title)
+	}
+}
@@ -0,0 +1,39 @@
+package pushover
+
+import (
+	"fmt"
+	"os"
+)
+
+// Environment variables read by NewFromEnv.
+const (
+	EnvAppToken = "PUSHOVER_APP_TOKEN"
+	EnvUserKey  = "PUSHOVER_USER_KEY"
+)
+
+// NewFromEnv returns a new app using the token from the PUSHOVER_APP_TOKEN
+// environment variable. If PUSHOVER_USER_KEY is set it's used as the default
+// recipient of the app. Both tokens are validated, the returned Errors name
+// the invalid variables.
+func NewFromEnv(opts ...Option) (*Pushover, error) {
+	var errs Errors
+
+	p := New(os.Getenv(EnvAppToken), opts...)
+	if err := p.validate(); err != nil {
+		errs = append(errs, fmt.Sprintf("%s: %v", EnvAppToken, err))
+	}
+
+	if key, ok := os.LookupEnv(EnvUserKey); ok {
+		recipient := NewRecipient(key)
+		if err := recipient.validate(); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", EnvUserKey, err))
+		}
+		p.SetDefaultRecipient(recipient)
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return p, nil
+}
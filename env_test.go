@@ -0,0 +1,113 @@
+package pushover
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// setEnv sets the environment variables and returns a function restoring
+// them, an empty value unsets the variable
+func setEnv(env map[string]string) func() {
+	previous := map[string]*string{}
+	for k, v := range env {
+		if old, ok := os.LookupEnv(k); ok {
+			previous[k] = &old
+		} else {
+			previous[k] = nil
+		}
+
+		if v == "" {
+			os.Unsetenv(k)
+		} else {
+			os.Setenv(k, v)
+		}
+	}
+
+	return func() {
+		for k, v := range previous {
+			if v == nil {
+				os.Unsetenv(k)
+			} else {
+				os.Setenv(k, *v)
+			}
+		}
+	}
+}
+
+// TestNewFromEnv tests the app configuration from the environment
+func TestNewFromEnv(t *testing.T) {
+	tt := []struct {
+		name              string
+		env               map[string]string
+		expectedRecipient *Recipient
+		expectedErr       error
+	}{
+		{
+			name:              "app and user",
+			env:               map[string]string{EnvAppToken: fakePushover.token, EnvUserKey: fakeRecipient.token},
+			expectedRecipient: fakeRecipient,
+		},
+		{
+			name: "app only",
+			env:  map[string]string{EnvAppToken: fakePushover.token, EnvUserKey: ""},
+		},
+		{
+			name: "invalid tokens",
+			env:  map[string]string{EnvAppToken: "", EnvUserKey: "invalid"},
+			expectedErr: Errors{
+				EnvAppToken + ": " + ErrEmptyToken.Error(),
+				EnvUserKey + ": " + ErrInvalidRecipientToken.Error(),
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			defer setEnv(tc.env)()
+
+			app, err := NewFromEnv()
+			if !reflect.DeepEqual(err, tc.expectedErr) {
+				t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(app.DefaultRecipient(), tc.expectedRecipient) {
+				t.Errorf("expected recipient %v, got %v", tc.expectedRecipient, app.DefaultRecipient())
+			}
+		})
+	}
+}
+
+// TestNotify tests the messages are sent to the default recipient
+func TestNotify(t *testing.T) {
+	var user string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user = r.FormValue("user")
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+
+	if _, err := app.Notify("Hello"); err != ErrEmptyRecipientToken {
+		t.Fatalf("expected %v, got %v", ErrEmptyRecipientToken, err)
+	}
+
+	app.SetDefaultRecipient(fakeRecipient)
+	if _, err := app.Notify("Hello"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if user != fakeRecipient.token {
+		t.Errorf("expected user %q, got %q", fakeRecipient.token, user)
+	}
+}
@@ -1,18 +1,120 @@
 package pushover
 
 import (
+	"fmt"
+	"net/http"
 	"strings"
+	"time"
 )
 
 // Errors represents the errors returned by pushover.
 type Errors []string
 
-// Error represents the error as a string.
+// NewError returns errors containing a single message.
+func NewError(msg string) Errors {
+	return Errors{msg}
+}
+
+// Error represents the error as a string. A single error is returned as is.
 func (e Errors) Error() string {
 	ret := ""
-	if len(e) > 0 {
+	switch len(e) {
+	case 0:
+	case 1:
+		ret = e[0]
+	default:
 		ret = "Errors:\n"
 		ret += strings.Join(e, "\n")
 	}
 	return ret
 }
+
+// APIError is returned when the API rejects a request, the request ID can be
+// used to find the request in the Pushover logs.
+type APIError struct {
+	RequestID  string
+	StatusCode int
+	Errors     Errors
+	// Invalid are the parameters flagged as invalid by the API, e.g. "user"
+	// or "device", in alphabetical order.
+	Invalid []string
+}
+
+// Error represents the error as a string, the request ID is appended to the
+// errors of the API.
+func (e *APIError) Error() string {
+	if e.RequestID == "" {
+		return e.Errors.Error()
+	}
+	return fmt.Sprintf("%v (request %s)", e.Errors, e.RequestID)
+}
+
+// Unwrap returns the errors of the API.
+func (e *APIError) Unwrap() error {
+	return e.Errors
+}
+
+// HTTPError is returned when the API fails with a server error, it matches
+// ErrHTTPPushover with errors.Is. It's wrapped in a RateLimitError when the
+// API gives a Retry-After header.
+type HTTPError struct {
+	StatusCode int
+	// RetryAfter is the delay given by the Retry-After header, it's zero if
+	// the header is missing.
+	RetryAfter time.Duration
+}
+
+// Error represents the error as a string.
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%v: %d %s", ErrHTTPPushover, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Unwrap returns ErrHTTPPushover.
+func (e *HTTPError) Unwrap() error {
+	return ErrHTTPPushover
+}
+
+// RateLimitError is returned when the API asks to slow down, either with a
+// 429 status code or with a Retry-After header on a server error.
+type RateLimitError struct {
+	// RetryAfter is the delay to wait before sending another request, it's
+	// zero if the API did not give one.
+	RetryAfter time.Duration
+	// Err is the underlying error.
+	Err error
+}
+
+// Error represents the error as a string.
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter == 0 {
+		return fmt.Sprintf("pushover: rate limited: %v", e.Err)
+	}
+	return fmt.Sprintf("pushover: rate limited, retry after %s: %v", e.RetryAfter, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// LengthError is returned when a field is too long, it matches the sentinel
+// error of the field such as ErrMessageTooLong with errors.Is.
+type LengthError struct {
+	// Field is the name of the field, e.g. "message".
+	Field string
+	// Got is the number of characters of the field and Max the limit.
+	Got int
+	Max int
+	// Err is the sentinel error of the field.
+	Err error
+}
+
+// Error represents the error as a string.
+func (e *LengthError) Error() string {
+	return fmt.Sprintf("%v (%d > %d)", e.Err, e.Got, e.Max)
+}
+
+// Unwrap returns the sentinel error of the field.
+func (e *LengthError) Unwrap() error {
+	return e.Err
+}
@@ -14,3 +14,35 @@ func TestErrorsString(t *testing.T) {
 		t.Errorf("invalid error string\ngot:\n%s\nexpected:\n%s\n", got, expected)
 	}
 }
+
+// TestSingleErrorString tests the string of a single error
+func TestSingleErrorString(t *testing.T) {
+	got := NewError("application token is invalid").Error()
+	expected := "application token is invalid"
+
+	if got != expected {
+		t.Errorf("invalid error string\ngot:\n%s\nexpected:\n%s\n", got, expected)
+	}
+}
+
+// TestAPIErrorString tests the request ID is appended to the errors
+func TestAPIErrorString(t *testing.T) {
+	e := &APIError{RequestID: "e460545a8b333d0da2f3602aff3133d6", Errors: NewError("user key is invalid")}
+	got := e.Error()
+	expected := "user key is invalid (request e460545a8b333d0da2f3602aff3133d6)"
+
+	if got != expected {
+		t.Errorf("invalid error string\ngot:\n%s\nexpected:\n%s\n", got, expected)
+	}
+}
+
+// TestHTTPErrorString tests the status code is part of the error
+func TestHTTPErrorString(t *testing.T) {
+	e := &HTTPError{StatusCode: 503}
+	got := e.Error()
+	expected := "pushover: http error: 503 Service Unavailable"
+
+	if got != expected {
+		t.Errorf("invalid error string\ngot:\n%s\nexpected:\n%s\n", got, expected)
+	}
+}
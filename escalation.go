@@ -0,0 +1,65 @@
+package pushover
+
+import (
+	"context"
+	"time"
+)
+
+// Emergency parameters used when escalating a message without retry or
+// expire values.
+const (
+	escalationRetry  = 60 * time.Second
+	escalationExpire = time.Hour
+)
+
+// SendWithEscalation sends the message and sends it again with an emergency
+// priority if the alert has not been handled after escalateAfter.
+//
+// Pushover does not return receipts for non emergency messages, there is no
+// way to know if the first message has been seen. The escalation is time
+// based: the caller signals that the alert has been handled by cancelling the
+// context, if it's not done after escalateAfter the message is sent again as
+// an emergency. The response of the last message sent is returned.
+func (p *Pushover) SendWithEscalation(ctx context.Context, message *Message, recipient *Recipient, escalateAfter time.Duration) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	response, err := p.SendMessageContext(ctx, message, recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nothing to escalate
+	if message.Priority == PriorityEmergency {
+		return response, nil
+	}
+
+	select {
+	case <-ctx.Done():
+		// The alert has been handled
+		return response, nil
+	case <-p.getClock().After(escalateAfter):
+	}
+
+	return p.SendMessageContext(ctx, escalate(message), recipient)
+}
+
+// escalate returns a copy of the message with an emergency priority, the
+// fields which can't be used with the emergency messages are reset and the
+// out of range retry and expire values are replaced by the defaults.
+func escalate(message *Message) *Message {
+	emergency := *message
+	emergency.Priority = PriorityEmergency
+	emergency.TTL = 0
+	// An escalation must not be skipped when the quota is low
+	emergency.BestEffort = false
+	if emergency.Retry < MessageMinRetry {
+		emergency.Retry = escalationRetry
+	}
+	if emergency.Expire <= 0 || emergency.Expire > MessageMaxExpire {
+		emergency.Expire = escalationExpire
+	}
+
+	return &emergency
+}
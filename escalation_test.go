@@ -0,0 +1,164 @@
+package pushover
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSendWithEscalation tests the escalation of unhandled messages
+func TestSendWithEscalation(t *testing.T) {
+	var mu sync.Mutex
+	var priorities []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		priorities = append(priorities, r.FormValue("priority"))
+		mu.Unlock()
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+
+	tt := []struct {
+		name     string
+		handled  bool
+		expected []string
+	}{
+		{"handled alert", true, []string{"0"}},
+		{"unhandled alert", false, []string{"0", "2"}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			priorities = nil
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if tc.handled {
+				go func() {
+					time.Sleep(10 * time.Millisecond)
+					cancel()
+				}()
+			}
+
+			message := NewMessage("Test message")
+			escalateAfter := 50 * time.Millisecond
+			if tc.handled {
+				escalateAfter = time.Minute
+			}
+
+			if _, err := app.SendWithEscalation(ctx, message, fakeRecipient, escalateAfter); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !reflect.DeepEqual(priorities, tc.expected) {
+				t.Errorf("expected priorities %v, got %v", tc.expected, priorities)
+			}
+
+			if message.Priority != PriorityNormal {
+				t.Errorf("the message should not be modified")
+			}
+		})
+	}
+}
+
+// TestSendWithEscalationTTL tests the TTL of a message is not kept once
+// escalated since it can't be used with the emergency priority
+func TestSendWithEscalationTTL(t *testing.T) {
+	var ttls []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ttls = append(ttls, r.FormValue("ttl"))
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6","receipt":"rLqVuqTRh62UzxtmqiaLzQmVcPgiCy"}`)
+	}))
+	defer ts.Close()
+
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+	app.SetClock(&fakeClock{now: time.Now()})
+
+	message := NewMessage("Test message")
+	message.TTL = time.Hour
+	if _, err := app.SendWithEscalation(context.Background(), message, fakeRecipient, time.Minute); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(ttls, []string{"3600", ""}) {
+		t.Errorf("unexpected TTLs %q", ttls)
+	}
+
+	if message.TTL != time.Hour {
+		t.Errorf("the message should not be modified")
+	}
+}
+
+// TestSendWithEscalationOptions tests the escalation of a message built with
+// the options of the normal messages
+func TestSendWithEscalationOptions(t *testing.T) {
+	var forms []map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form := map[string]string{}
+		for _, key := range []string{"priority", "title", "sound", "url", "url_title", "device", "html", "retry", "expire", "ttl"} {
+			form[key] = r.FormValue(key)
+		}
+		forms = append(forms, form)
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6","receipt":"rLqVuqTRh62UzxtmqiaLzQmVcPgiCy"}`)
+	}))
+	defer ts.Close()
+
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+	app.SetClock(&fakeClock{now: time.Now()})
+
+	message := NewMessageWith("Test message",
+		WithTitle("Database down"),
+		WithPriority(PriorityHigh),
+		WithSound(SoundSiren),
+		WithURL("http://example.com", "Dashboard"),
+		WithDevice("phone"),
+		WithHTML(),
+	)
+	message.TTL = time.Hour
+	message.BestEffort = true
+	message.Retry = 10 * time.Second
+
+	if _, err := app.SendWithEscalation(context.Background(), message, fakeRecipient, time.Minute); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(forms) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(forms))
+	}
+
+	expected := map[string]string{
+		"priority":  "2",
+		"title":     "Database down",
+		"sound":     SoundSiren,
+		"url":       "http://example.com",
+		"url_title": "Dashboard",
+		"device":    "phone",
+		"html":      "1",
+		"retry":     "60",
+		"expire":    "3600",
+		"ttl":       "",
+	}
+	if !reflect.DeepEqual(forms[1], expected) {
+		t.Errorf("invalid escalated message\nexpected: %v\ngot: %v", expected, forms[1])
+	}
+}
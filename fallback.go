@@ -0,0 +1,53 @@
+package pushover
+
+import (
+	"context"
+	"errors"
+)
+
+// SendWithFallback sends the message to the primary recipient and, if the
+// recipient is rejected (invalid or disabled user key), tries each fallback
+// recipient in order. Other errors such as an invalid app token or a network
+// error are returned right away. The Recipient field of the response is set
+// to the recipient that received the message.
+func (p *Pushover) SendWithFallback(ctx context.Context, message *Message, primary *Recipient, fallbacks ...*Recipient) (*Response, error) {
+	var err error
+	for _, recipient := range append([]*Recipient{primary}, fallbacks...) {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+
+		var response *Response
+		response, err = p.SendMessageContext(ctx, message, recipient)
+		if err == nil {
+			response.Recipient = recipient
+			return response, nil
+		}
+
+		if !isRecipientError(err) {
+			return nil, err
+		}
+	}
+
+	return nil, err
+}
+
+// isRecipientError returns true if the error is caused by the recipient.
+func isRecipientError(err error) bool {
+	switch err {
+	case ErrEmptyRecipientToken, ErrInvalidRecipientToken:
+		return true
+	}
+
+	// The API flags the rejected recipient in the user or group parameter
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		for _, param := range apiErr.Invalid {
+			if param == "user" || param == "group" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,96 @@
+package pushover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestSendWithFallback tests the fallback recipients are used when the
+// primary recipient is rejected
+func TestSendWithFallback(t *testing.T) {
+	invalidRecipient := NewRecipient("uQiRzpo4DXghDmr9QzzfQu27cmVRsG")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("user") == invalidRecipient.token {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, `{"user":"invalid","errors":["user identifier is not a valid user, group, or subscribed user key"],"status":0,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+			return
+		}
+
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+	ctx := context.Background()
+
+	tt := []struct {
+		name      string
+		primary   *Recipient
+		fallbacks []*Recipient
+		expected  *Recipient
+		fails     bool
+	}{
+		{"valid primary", fakeRecipient, []*Recipient{invalidRecipient}, fakeRecipient, false},
+		{"invalid primary", invalidRecipient, []*Recipient{fakeRecipient}, fakeRecipient, false},
+		{"malformed primary", NewRecipient("invalid"), []*Recipient{invalidRecipient, fakeRecipient}, fakeRecipient, false},
+		{"no valid recipient", invalidRecipient, nil, nil, true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := app.SendWithFallback(ctx, NewMessage("Hello"), tc.primary, tc.fallbacks...)
+			if tc.fails {
+				if err == nil {
+					t.Fatalf("expected an error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if response.Recipient != tc.expected {
+				t.Errorf("expected recipient %v, got %v", tc.expected, response.Recipient)
+			}
+		})
+	}
+
+	// The app errors should not trigger a fallback
+	invalidApp := New("invalid")
+	if _, err := invalidApp.SendWithFallback(ctx, NewMessage("Hello"), fakeRecipient, fakeRecipient); err != ErrInvalidToken {
+		t.Fatalf("expected %v, got %v", ErrInvalidToken, err)
+	}
+}
+
+// TestIsRecipientError tests the recipient errors are detected with the
+// invalid parameters of the API errors
+func TestIsRecipientError(t *testing.T) {
+	tt := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"malformed token", ErrInvalidRecipientToken, true},
+		{"invalid user", &APIError{Errors: Errors{"user identifier is invalid"}, Invalid: []string{"user"}}, true},
+		{"invalid group", &APIError{Errors: Errors{"group is disabled"}, Invalid: []string{"group"}}, true},
+		{"invalid device", &APIError{Errors: Errors{"device name is not valid for user"}, Invalid: []string{"device"}}, false},
+		{"message mentioning the user", &APIError{Errors: Errors{"message cannot be blank for this user"}}, false},
+		{"network error", errors.New("connection refused"), false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isRecipientError(tc.err); got != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, got)
+			}
+		})
+	}
+}
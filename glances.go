@@ -2,9 +2,8 @@ package pushover
 
 import (
 	"fmt"
+	"net/http"
 	"strconv"
-	"strings"
-	"unicode/utf8"
 )
 
 const (
@@ -50,34 +49,32 @@ func (m *Glance) validate() error {
 	if m.Title == nil && m.Text == nil && m.Subtext == nil && m.Count == nil && m.Percent == nil {
 		return ErrGlancesMissingData
 	}
-	if m.Title != nil && utf8.RuneCountInString(*m.Title) > GlancesMessageMaxTitleLength {
-		return ErrGlancesTitleTooLong
+	if m.Title != nil {
+		if err := checkLength("title", *m.Title, GlancesMessageMaxTitleLength, ErrGlancesTitleTooLong); err != nil {
+			return err
+		}
 	}
-	if m.Text != nil && utf8.RuneCountInString(*m.Text) > GlancesMessageMaxTextLength {
-		return ErrGlancesTextTooLong
+	if m.Text != nil {
+		if err := checkLength("text", *m.Text, GlancesMessageMaxTextLength, ErrGlancesTextTooLong); err != nil {
+			return err
+		}
 	}
-	if m.Subtext != nil && utf8.RuneCountInString(*m.Subtext) > GlancesMessageMaxSubtextLength {
-		return ErrGlancesSubtextTooLong
+	if m.Subtext != nil {
+		if err := checkLength("subtext", *m.Subtext, GlancesMessageMaxSubtextLength, ErrGlancesSubtextTooLong); err != nil {
+			return err
+		}
 	}
 	if m.Percent != nil && (*m.Percent < 0 || *m.Percent > 100) {
 		return ErrGlancesInvalidPercent
 	}
 	// Test device name
-	if m.DeviceName != "" {
-		// Accept comma separated device names
-		devices := strings.Split(m.DeviceName, ",")
-		for _, d := range devices {
-			if !deviceNameRegexp.MatchString(d) {
-				return ErrInvalidDeviceName
-			}
-		}
-	}
-	return nil
+	return validateDeviceNames(m.DeviceName)
 }
 
-// send sends the message using the pushover and the recipient tokens.
-func (m *Glance) send(pToken, rToken string) (*Response, error) {
-	url := fmt.Sprintf("%s/glances.json", APIEndpoint)
+// request returns the request to send the glance to the API endpoint using
+// the pushover and the recipient tokens.
+func (m *Glance) request(endpoint, pToken, rToken string) (*http.Request, error) {
+	url := fmt.Sprintf("%s/glances.json", endpoint)
 
 	params := map[string]string{
 		"token": pToken,
@@ -104,15 +101,5 @@ func (m *Glance) send(pToken, rToken string) (*Response, error) {
 		params["subtext"] = *m.Subtext
 	}
 
-	req, err := newURLEncodedRequest("POST", url, params)
-	if err != nil {
-		return nil, err
-	}
-
-	resp := new(Response)
-	if err = do(req, resp, true); err != nil {
-		return nil, err
-	}
-
-	return resp, nil
+	return newURLEncodedRequest("POST", url, params)
 }
@@ -1,7 +1,16 @@
 package pushover
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestGlancesValidation(t *testing.T) {
@@ -64,6 +73,14 @@ func TestGlancesValidation(t *testing.T) {
 			},
 			expectedErr: ErrInvalidDeviceName,
 		},
+		{
+			name: "invalid multiple devices",
+			fields: &Glance{
+				Title:      String("hi!"),
+				DeviceName: "device1,,device2",
+			},
+			expectedErr: ErrInvalidDeviceName,
+		},
 		{
 			name: "missing data",
 			fields: &Glance{
@@ -82,9 +99,184 @@ func TestGlancesValidation(t *testing.T) {
 				Percent:    tt.fields.Percent,
 				DeviceName: tt.fields.DeviceName,
 			}
-			if err := m.validate(); err != tt.expectedErr {
+			if err := m.validate(); !errors.Is(err, tt.expectedErr) {
 				t.Errorf("validate() error = %v, expected err %v", err, tt.expectedErr)
 			}
 		})
 	}
 }
+
+// TestGlancesLengthError tests the lengths are given with the errors
+func TestGlancesLengthError(t *testing.T) {
+	glance := &Glance{Title: String(strings.Repeat("a", GlancesMessageMaxTitleLength+1))}
+	err := glance.validate()
+
+	var lengthErr *LengthError
+	if !errors.As(err, &lengthErr) {
+		t.Fatalf("expected a LengthError, got %v", err)
+	}
+
+	expected := &LengthError{Field: "title", Got: GlancesMessageMaxTitleLength + 1, Max: GlancesMessageMaxTitleLength, Err: ErrGlancesTitleTooLong}
+	if !reflect.DeepEqual(lengthErr, expected) {
+		t.Errorf("expected %v, got %v", expected, lengthErr)
+	}
+}
+
+// TestGlanceCoalesce tests that only the latest glance update of an interval
+// is sent
+func TestGlanceCoalesce(t *testing.T) {
+	counts := make(chan string, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		counts <- r.FormValue("count")
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+	clock := &manualClock{now: time.Unix(1393653600, 0)}
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+	app.SetClock(clock)
+	app.SetGlanceCoalesce(time.Minute)
+
+	for i, expectedCoalesced := range []bool{false, true, true} {
+		response, err := app.SendGlanceUpdate(&Glance{Count: Int(i)}, fakeRecipient)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if response.Coalesced != expectedCoalesced {
+			t.Errorf("expected coalesced to be %t for update %d", expectedCoalesced, i)
+		}
+	}
+
+	if got := <-counts; got != "0" {
+		t.Fatalf("expected the first update to be sent, got %q", got)
+	}
+
+	// The interval is not over
+	clock.Advance(time.Minute - time.Second)
+	select {
+	case got := <-counts:
+		t.Fatalf("unexpected update %q before the end of the interval", got)
+	default:
+	}
+
+	// The latest update is sent at the end of the interval
+	clock.Advance(time.Second)
+	if got := <-counts; got != "2" {
+		t.Errorf("expected the latest update to be sent, got %q", got)
+	}
+
+	// A new update is coalesced with the one just sent
+	response, err := app.SendGlanceUpdate(&Glance{Count: Int(3)}, fakeRecipient)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !response.Coalesced {
+		t.Errorf("expected the update to be coalesced")
+	}
+
+	if err := app.Flush(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if got := <-counts; got != "3" {
+		t.Errorf("expected the flushed update to be sent, got %q", got)
+	}
+}
+
+// TestGlanceFlush tests the pending glance updates are sent by Flush
+func TestGlanceFlush(t *testing.T) {
+	var mu sync.Mutex
+	var counts []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		counts = append(counts, r.FormValue("count"))
+		mu.Unlock()
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+
+	// Nothing to flush
+	if err := app.Flush(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	app.SetGlanceCoalesce(time.Hour)
+	for i := 0; i < 3; i++ {
+		if _, err := app.SendGlanceUpdate(&Glance{Count: Int(i)}, fakeRecipient); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if err := app.Flush(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	expected := []string{"0", "2"}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("expected updates %v, got %v", expected, counts)
+	}
+}
+
+// TestSendGlance tests a glance update is sent to the API
+func TestSendGlance(t *testing.T) {
+	var params map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse form: %v", err)
+		}
+		params = map[string]string{}
+		for k := range r.PostForm {
+			params[k] = r.PostForm.Get(k)
+		}
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+	got, err := app.SendGlance(&Glance{Title: String("Widgets"), Count: Int(42)}, fakeRecipient)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := &Response{
+		Status: 1,
+		ID:     "e460545a8b333d0da2f3602aff3133d6",
+		Limit: &Limit{
+			Total:     7500,
+			Remaining: 6000,
+			NextReset: time.Unix(int64(1393653600), 0),
+		},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected response %v", got)
+	}
+
+	expectedParams := map[string]string{
+		"token": fakePushover.token,
+		"user":  fakeRecipient.token,
+		"title": "Widgets",
+		"count": "42",
+	}
+
+	if !reflect.DeepEqual(params, expectedParams) {
+		t.Errorf("expected params %v, got %v", expectedParams, params)
+	}
+
+	if _, err := app.SendGlance(&Glance{Count: Int(1)}, NewRecipient("")); err != ErrEmptyRecipientToken {
+		t.Fatalf("expected %v, got %v", ErrEmptyRecipientToken, err)
+	}
+}
@@ -0,0 +1,195 @@
+package pushover
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Group represents a delivery group.
+type Group struct {
+	Name  string      `json:"name"`
+	Users []GroupUser `json:"users"`
+}
+
+// GroupUser represents a member of a delivery group, the Device is empty if
+// the notifications are sent to all the devices of the user.
+type GroupUser struct {
+	User     string `json:"user"`
+	Device   string `json:"device"`
+	Memo     string `json:"memo"`
+	Disabled bool   `json:"disabled"`
+}
+
+// groupResponse represents the response of the group details.
+type groupResponse struct {
+	Response
+	Group
+}
+
+// groupCreateResponse represents the response of a group creation.
+type groupCreateResponse struct {
+	Response
+	Group string `json:"group"`
+}
+
+// CreateGroup creates a new delivery group with the given name and returns
+// its key, which can be used as a recipient token.
+func (p *Pushover) CreateGroup(name string) (string, error) {
+	endpoint := fmt.Sprintf("%s/groups.json", p.Endpoint())
+
+	// Validate pushover
+	if err := p.validate(); err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(name) == "" {
+		return "", ErrEmptyGroupName
+	}
+
+	req, err := newURLEncodedRequest("POST", endpoint,
+		map[string]string{"token": p.token, "name": name})
+	if err != nil {
+		return "", err
+	}
+
+	response := &groupCreateResponse{}
+	if err := p.do(req, response, false); err != nil {
+		return "", err
+	}
+
+	return response.Group, nil
+}
+
+// GetGroup returns the name and the members of a delivery group.
+func (p *Pushover) GetGroup(groupKey string) (*Group, error) {
+	// Validate pushover
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := validateGroupKey(groupKey); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/groups/%s.json?token=%s", p.Endpoint(), groupKey, p.token)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &groupResponse{}
+	if err := p.do(req, response, false); err != nil {
+		return nil, err
+	}
+
+	return &response.Group, nil
+}
+
+// validateGroupKey validates the key of a group, it has the format of a
+// recipient token.
+func validateGroupKey(groupKey string) error {
+	if !recipientRegexp.MatchString(groupKey) {
+		return ErrInvalidGroupKey
+	}
+
+	return nil
+}
+
+// AddUserToGroup adds a user to a delivery group, the device and the memo are
+// optional.
+func (p *Pushover) AddUserToGroup(groupKey, userKey, device, memo string) (*Response, error) {
+	if err := validateDeviceNames(device); err != nil {
+		return nil, err
+	}
+
+	params := map[string]string{}
+	if device != "" {
+		params["device"] = device
+	}
+	if memo != "" {
+		params["memo"] = memo
+	}
+
+	return p.groupUserAction(groupKey, userKey, "add_user", params)
+}
+
+// RemoveUserFromGroup removes a user from a delivery group.
+func (p *Pushover) RemoveUserFromGroup(groupKey, userKey string) (*Response, error) {
+	return p.groupUserAction(groupKey, userKey, "remove_user", map[string]string{})
+}
+
+// DisableUserInGroup disables a member of a delivery group, the user stops
+// receiving the notifications sent to the group until enabled again. The
+// device is optional.
+func (p *Pushover) DisableUserInGroup(groupKey, userKey, device string) (*Response, error) {
+	return p.groupUserDeviceAction(groupKey, userKey, device, "disable_user")
+}
+
+// EnableUserInGroup enables a member of a delivery group disabled by
+// DisableUserInGroup. The device is optional.
+func (p *Pushover) EnableUserInGroup(groupKey, userKey, device string) (*Response, error) {
+	return p.groupUserDeviceAction(groupKey, userKey, device, "enable_user")
+}
+
+// groupUserDeviceAction posts an action about a user and an optional device
+// to the endpoint of a group.
+func (p *Pushover) groupUserDeviceAction(groupKey, userKey, device, action string) (*Response, error) {
+	if err := validateDeviceNames(device); err != nil {
+		return nil, err
+	}
+
+	params := map[string]string{}
+	if device != "" {
+		params["device"] = device
+	}
+
+	return p.groupUserAction(groupKey, userKey, action, params)
+}
+
+// RenameGroup renames a delivery group.
+func (p *Pushover) RenameGroup(groupKey, newName string) (*Response, error) {
+	if strings.TrimSpace(newName) == "" {
+		return nil, ErrEmptyGroupName
+	}
+
+	return p.groupAction(groupKey, "rename", map[string]string{"name": newName})
+}
+
+// groupUserAction posts an action about a user to the endpoint of a group,
+// e.g. "add_user".
+func (p *Pushover) groupUserAction(groupKey, userKey, action string, params map[string]string) (*Response, error) {
+	if err := NewRecipient(userKey).validate(); err != nil {
+		return nil, err
+	}
+
+	params["user"] = userKey
+	return p.groupAction(groupKey, action, params)
+}
+
+// groupAction posts an action to the endpoint of a group, the app token is
+// added to the params.
+func (p *Pushover) groupAction(groupKey, action string, params map[string]string) (*Response, error) {
+	// Validate pushover
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := validateGroupKey(groupKey); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/groups/%s/%s.json", p.Endpoint(), groupKey, action)
+	params["token"] = p.token
+	req, err := newURLEncodedRequest("POST", endpoint, params)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &Response{}
+	if err := p.do(req, response, false); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
@@ -0,0 +1,249 @@
+package pushover
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// TestCreateGroup tests the creation of a group
+func TestCreateGroup(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/groups.json" || r.FormValue("name") != "On call" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, `{"status":0,"request":"e460545a8b333d0da2f3602aff3133d6","errors":["name is invalid"]}`)
+			return
+		}
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6","group":"gznej3rKEVAvPUxu9vvNnqpmZpokzF"}`)
+	}))
+	defer ts.Close()
+
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+
+	tt := []struct {
+		name        string
+		groupName   string
+		expected    string
+		expectedErr error
+	}{
+		{"valid", "On call", "gznej3rKEVAvPUxu9vvNnqpmZpokzF", nil},
+		{"empty name", " ", "", ErrEmptyGroupName},
+		{"rejected", "Other", "", &APIError{RequestID: "e460545a8b333d0da2f3602aff3133d6", StatusCode: http.StatusBadRequest, Errors: Errors{"name is invalid"}}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := app.CreateGroup(tc.groupName)
+			if !reflect.DeepEqual(err, tc.expectedErr) {
+				t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if got != tc.expected {
+				t.Errorf("expected group %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestGetGroup tests the details of a group are fetched from the API
+func TestGetGroup(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/groups/gznej3rKEVAvPUxu9vvNnqpmZpokzF.json" || r.URL.Query().Get("token") != fakePushover.token {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, `{"status":0,"request":"e460545a8b333d0da2f3602aff3133d6","errors":["group not found"]}`)
+			return
+		}
+		fmt.Fprintln(w, `{"name":"On call","users":[{"user":"uQiRzpo4DXghDmr9QzzfQu27cmVRsG","device":"phone","memo":"Bob","disabled":false},{"user":"gznej3rKEVAvPUxu9vvNnqpmZpokzF","device":null,"memo":"","disabled":true}],"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+	got, err := app.GetGroup("gznej3rKEVAvPUxu9vvNnqpmZpokzF")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := &Group{
+		Name: "On call",
+		Users: []GroupUser{
+			{User: "uQiRzpo4DXghDmr9QzzfQu27cmVRsG", Device: "phone", Memo: "Bob"},
+			{User: "gznej3rKEVAvPUxu9vvNnqpmZpokzF", Disabled: true},
+		},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+
+	if _, err := app.GetGroup("invalid"); err != ErrInvalidGroupKey {
+		t.Errorf("expected %v, got %v", ErrInvalidGroupKey, err)
+	}
+
+	if _, err := app.GetGroup("uQiRzpo4DXghDmr9QzzfQu27cmVRsG"); err == nil {
+		t.Errorf("expected an error for an unknown group")
+	}
+}
+
+// newGroupServer returns a server recording the path and the form of the
+// requests, the requests about the user uQiRzpo4DXghDmr9QzzfQu27cmVRsG are
+// rejected
+func newGroupServer(t *testing.T) (*httptest.Server, *http.Request) {
+	got := &http.Request{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse the form: %v", err)
+		}
+		got.URL, got.PostForm = r.URL, r.PostForm
+
+		if r.PostForm.Get("user") == "uQiRzpo4DXghDmr9QzzfQu27cmVRsG" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, `{"status":0,"request":"e460545a8b333d0da2f3602aff3133d6","errors":["user is not a member of this group"]}`)
+			return
+		}
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+
+	return ts, got
+}
+
+// TestGroupMembership tests the users are added to and removed from a group
+func TestGroupMembership(t *testing.T) {
+	ts, got := newGroupServer(t)
+	defer ts.Close()
+
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+	group := "gznej3rKEVAvPUxu9vvNnqpmZpokzF"
+	user := "uyosdopsu8o9sfd3ndo42vyzefepat"
+
+	if _, err := app.AddUserToGroup(group, user, "phone", "Bob"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if got.URL.Path != "/groups/"+group+"/add_user.json" {
+		t.Errorf("unexpected path %q", got.URL.Path)
+	}
+
+	expected := url.Values{
+		"token":  {fakePushover.token},
+		"user":   {user},
+		"device": {"phone"},
+		"memo":   {"Bob"},
+	}
+	if !reflect.DeepEqual(got.PostForm, expected) {
+		t.Errorf("expected %v, got %v", expected, got.PostForm)
+	}
+
+	if _, err := app.RemoveUserFromGroup(group, user); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if got.URL.Path != "/groups/"+group+"/remove_user.json" {
+		t.Errorf("unexpected path %q", got.URL.Path)
+	}
+
+	expected = url.Values{"token": {fakePushover.token}, "user": {user}}
+	if !reflect.DeepEqual(got.PostForm, expected) {
+		t.Errorf("expected %v, got %v", expected, got.PostForm)
+	}
+
+	var apiErr *APIError
+	if _, err := app.RemoveUserFromGroup(group, "uQiRzpo4DXghDmr9QzzfQu27cmVRsG"); !errors.As(err, &apiErr) {
+		t.Errorf("expected an APIError, got %v", err)
+	}
+
+	if _, err := app.AddUserToGroup("invalid", user, "", ""); err != ErrInvalidGroupKey {
+		t.Errorf("expected %v, got %v", ErrInvalidGroupKey, err)
+	}
+
+	if _, err := app.AddUserToGroup(group, "invalid", "", ""); err != ErrInvalidRecipientToken {
+		t.Errorf("expected %v, got %v", ErrInvalidRecipientToken, err)
+	}
+
+	if _, err := app.AddUserToGroup(group, user, "my^device", ""); err != ErrInvalidDeviceName {
+		t.Errorf("expected %v, got %v", ErrInvalidDeviceName, err)
+	}
+}
+
+// TestGroupUserStatus tests the members of a group are disabled and enabled
+func TestGroupUserStatus(t *testing.T) {
+	ts, got := newGroupServer(t)
+	defer ts.Close()
+
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+	group := "gznej3rKEVAvPUxu9vvNnqpmZpokzF"
+	user := "uyosdopsu8o9sfd3ndo42vyzefepat"
+
+	tt := []struct {
+		name     string
+		action   func(groupKey, userKey, device string) (*Response, error)
+		device   string
+		path     string
+		expected url.Values
+	}{
+		{
+			name:     "disable",
+			action:   app.DisableUserInGroup,
+			device:   "phone",
+			path:     "/groups/" + group + "/disable_user.json",
+			expected: url.Values{"token": {fakePushover.token}, "user": {user}, "device": {"phone"}},
+		},
+		{
+			name:     "enable",
+			action:   app.EnableUserInGroup,
+			path:     "/groups/" + group + "/enable_user.json",
+			expected: url.Values{"token": {fakePushover.token}, "user": {user}},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.action(group, user, tc.device); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if got.URL.Path != tc.path {
+				t.Errorf("unexpected path %q", got.URL.Path)
+			}
+
+			if !reflect.DeepEqual(got.PostForm, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, got.PostForm)
+			}
+
+			if _, err := tc.action(group, "invalid", ""); err != ErrInvalidRecipientToken {
+				t.Errorf("expected %v, got %v", ErrInvalidRecipientToken, err)
+			}
+		})
+	}
+}
+
+// TestRenameGroup tests the new name of the group is sent
+func TestRenameGroup(t *testing.T) {
+	ts, got := newGroupServer(t)
+	defer ts.Close()
+
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+	group := "gznej3rKEVAvPUxu9vvNnqpmZpokzF"
+
+	if _, err := app.RenameGroup(group, "Night shift"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if got.URL.Path != "/groups/"+group+"/rename.json" {
+		t.Errorf("unexpected path %q", got.URL.Path)
+	}
+
+	if name := got.PostForm.Get("name"); name != "Night shift" {
+		t.Errorf("expected the name Night shift, got %q", name)
+	}
+
+	if _, err := app.RenameGroup(group, " "); err != ErrEmptyGroupName {
+		t.Errorf("expected %v, got %v", ErrEmptyGroupName, err)
+	}
+
+	if _, err := app.RenameGroup("invalid", "Night shift"); err != ErrInvalidGroupKey {
+		t.Errorf("expected %v, got %v", ErrInvalidGroupKey, err)
+	}
+}
@@ -1,18 +1,35 @@
 package pushover
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 )
 
-// Helper to unmarshal a timestamp as string to a time.Time.
+// Helper to unmarshal a timestamp to a time.Time, the timestamp can either be
+// a JSON number or a numeric string.
 type timestamp struct{ *time.Time }
 
 func (t *timestamp) UnmarshalJSON(data []byte) error {
-	var i int64
-	if err := json.Unmarshal(data, &i); err != nil {
-		return err
+	s := string(data)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+
+	if s == "" || s == "null" {
+		return nil
+	}
+
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal timestamp: %w", err)
 	}
 
 	if i > 0 {
@@ -43,3 +60,126 @@ func (i *intBool) UnmarshalJSON(data []byte) error {
 
 	return nil
 }
+
+// Helper to parse a Retry-After header value, it can either be a number of
+// seconds or a date relative to now. Zero is returned if the value is missing
+// or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0
+	}
+
+	d := date.Sub(now)
+	if d < 0 {
+		return 0
+	}
+
+	return d
+}
+
+// invalidParams returns the parameters flagged as invalid in the body of a
+// response, e.g. {"user":"invalid"}, in alphabetical order.
+func invalidParams(body []byte) []string {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil
+	}
+
+	var params []string
+	for k, v := range fields {
+		if v == "invalid" {
+			params = append(params, k)
+		}
+	}
+	sort.Strings(params)
+
+	return params
+}
+
+// checkLength returns a LengthError if the number of characters of the field
+// exceeds the max.
+func checkLength(field, value string, max int, err error) error {
+	if n := utf8.RuneCountInString(value); n > max {
+		return &LengthError{Field: field, Got: n, Max: max, Err: err}
+	}
+
+	return nil
+}
+
+// validateDeviceNames validates the device names separated by
+// DeviceSeparator, an empty value targets all the devices.
+func validateDeviceNames(names string) error {
+	if names == "" {
+		return nil
+	}
+
+	for _, d := range strings.Split(names, DeviceSeparator) {
+		if !deviceNameRegexp.MatchString(d) {
+			return ErrInvalidDeviceName
+		}
+	}
+
+	return nil
+}
+
+// JoinDevices returns the device names separated by DeviceSeparator, to be
+// used as the device name of a message or a glance. Device names containing
+// the separator are rejected with ErrDeviceNameSeparator.
+func JoinDevices(devices ...string) (string, error) {
+	for _, d := range devices {
+		if strings.Contains(d, DeviceSeparator) {
+			return "", ErrDeviceNameSeparator
+		}
+
+		if !deviceNameRegexp.MatchString(d) {
+			return "", ErrInvalidDeviceName
+		}
+	}
+
+	return strings.Join(devices, DeviceSeparator), nil
+}
+
+// concurrentRequests is the max number of requests sent at the same time by
+// the batch helpers.
+const concurrentRequests = 4
+
+// forEach calls fn for each index up to n from concurrentRequests goroutines
+// and waits for the calls to return. No call is started once the context is
+// done.
+func forEach(ctx context.Context, n int, fn func(i int)) {
+	sem := make(chan struct{}, concurrentRequests)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			return
+		case sem <- struct{}{}:
+		}
+
+		if ctx.Err() != nil {
+			<-sem
+			return
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+}
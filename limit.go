@@ -1,6 +1,7 @@
 package pushover
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -17,7 +18,7 @@ type Limit struct {
 	Total int
 	// Remaining number of messages you can send until the next reset.
 	Remaining int
-	// NextReset is the time when all the app counters will be reseted.
+	// NextReset is the time when all the app counters will be reset.
 	NextReset time.Time
 }
 
@@ -55,3 +56,47 @@ func newLimit(headers http.Header) (*Limit, error) {
 		NextReset: time.Unix(int64(headersValues["X-Limit-App-Reset"]), 0),
 	}, nil
 }
+
+// ResetDate returns the moment the app counters will be reset in the local
+// time of the caller. Pushover resets the counters monthly, at the beginning
+// of each month.
+func (l *Limit) ResetDate() time.Time {
+	return l.NextReset.Local()
+}
+
+// limitsResponse represents the response of the limits endpoint.
+type limitsResponse struct {
+	Response
+	Limit     int   `json:"limit"`
+	Remaining int   `json:"remaining"`
+	Reset     int64 `json:"reset"`
+}
+
+// GetLimits returns the limits of the app without sending a message. The
+// limits are kept for RemainingMessages and the quota checks as well.
+func (p *Pushover) GetLimits() (*Limit, error) {
+	// Validate pushover
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/apps/limits.json?token=%s", p.Endpoint(), p.token)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &limitsResponse{}
+	if err := p.do(req, response, false); err != nil {
+		return nil, err
+	}
+
+	response.Response.Limit = &Limit{
+		Total:     response.Limit,
+		Remaining: response.Remaining,
+		NextReset: time.Unix(response.Reset, 0),
+	}
+	p.cacheLimit(&response.Response)
+
+	return response.Response.Limit, nil
+}
@@ -0,0 +1,45 @@
+package pushover
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestGetLimits tests the limits are fetched from the API
+func TestGetLimits(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/apps/limits.json" || r.URL.Query().Get("token") != fakePushover.token {
+			fmt.Fprintln(w, `{"status":0,"request":"e460545a8b333d0da2f3602aff3133d6","errors":["application token is invalid"]}`)
+			return
+		}
+		fmt.Fprintln(w, `{"limit":10000,"remaining":7496,"reset":1393653600,"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+	got, err := app.GetLimits()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := &Limit{
+		Total:     10000,
+		Remaining: 7496,
+		NextReset: time.Unix(1393653600, 0),
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+
+	if remaining, _, ok := app.RemainingMessages(); !ok || remaining != 7496 {
+		t.Errorf("expected the limits to be cached, got %d", remaining)
+	}
+
+	if _, err := New("invalid").GetLimits(); err != ErrInvalidToken {
+		t.Errorf("expected %v, got %v", ErrInvalidToken, err)
+	}
+}
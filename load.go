@@ -0,0 +1,104 @@
+package pushover
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formats of the recipient lists read by LoadRecipients.
+const (
+	RecipientsCSV  = "csv"
+	RecipientsJSON = "json"
+)
+
+// recipientEntry represents a recipient in a list.
+type recipientEntry struct {
+	Token  string `json:"token"`
+	Label  string `json:"label"`
+	Device string `json:"device"`
+}
+
+// LoadRecipients reads a list of recipients in the CSV or JSON format.
+//
+// The CSV rows are made of the token followed by an optional label and
+// device name, a first row starting with "token" is considered as a header.
+// The JSON document is an array of objects with the "token", "label" and
+// "device" fields.
+//
+// The valid recipients are returned along with an Errors listing the invalid
+// rows, numbered from 1 without the CSV header.
+func LoadRecipients(r io.Reader, format string) ([]*Recipient, error) {
+	var entries []recipientEntry
+	var err error
+	switch strings.ToLower(format) {
+	case RecipientsCSV:
+		entries, err = readCSVRecipients(r)
+	case RecipientsJSON:
+		err = json.NewDecoder(r).Decode(&entries)
+	default:
+		return nil, ErrInvalidRecipientsFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var recipients []*Recipient
+	var errs Errors
+	for i, entry := range entries {
+		recipient := &Recipient{
+			token:  strings.TrimSpace(entry.Token),
+			label:  strings.TrimSpace(entry.Label),
+			device: strings.TrimSpace(entry.Device),
+		}
+
+		if err := recipient.validate(); err != nil {
+			errs = append(errs, fmt.Sprintf("row %d: %v", i+1, err))
+			continue
+		}
+
+		recipients = append(recipients, recipient)
+	}
+
+	if len(errs) > 0 {
+		return recipients, errs
+	}
+
+	return recipients, nil
+}
+
+// readCSVRecipients reads the recipients of a CSV list.
+func readCSVRecipients(r io.Reader) ([]recipientEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) > 0 && len(rows[0]) > 0 && strings.EqualFold(rows[0][0], "token") {
+		rows = rows[1:]
+	}
+
+	entries := make([]recipientEntry, 0, len(rows))
+	for _, row := range rows {
+		var entry recipientEntry
+		for i, field := range row {
+			switch i {
+			case 0:
+				entry.Token = field
+			case 1:
+				entry.Label = field
+			case 2:
+				entry.Device = field
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,63 @@
+package pushover
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestLoadRecipients tests the recipient lists are read
+func TestLoadRecipients(t *testing.T) {
+	expected := []*Recipient{
+		{token: "gznej3rKEVAvPUxu9vvNnqpmZpokzF", label: "Alice"},
+		{token: "uQiRzpo4DXghDmr9QzzfQu27cmVRsG", label: "Bob", device: "phone"},
+	}
+	expectedErrs := Errors{
+		"row 3: " + ErrInvalidRecipientToken.Error(),
+		"row 4: " + ErrInvalidDeviceName.Error(),
+	}
+
+	tt := []struct {
+		name   string
+		format string
+		input  string
+	}{
+		{
+			name:   "csv",
+			format: RecipientsCSV,
+			input: `token,label,device
+gznej3rKEVAvPUxu9vvNnqpmZpokzF,Alice
+uQiRzpo4DXghDmr9QzzfQu27cmVRsG, Bob, phone
+invalid
+gznej3rKEVAvPUxu9vvNnqpmZpokzF,Carol,phone!
+`,
+		},
+		{
+			name:   "json",
+			format: RecipientsJSON,
+			input: `[
+				{"token": "gznej3rKEVAvPUxu9vvNnqpmZpokzF", "label": "Alice"},
+				{"token": "uQiRzpo4DXghDmr9QzzfQu27cmVRsG", "label": "Bob", "device": "phone"},
+				{"token": "invalid"},
+				{"token": "gznej3rKEVAvPUxu9vvNnqpmZpokzF", "label": "Carol", "device": "phone!"}
+			]`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := LoadRecipients(strings.NewReader(tc.input), tc.format)
+			if !reflect.DeepEqual(err, expectedErrs) {
+				t.Errorf("expected errors %v, got %v", expectedErrs, err)
+			}
+
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("expected %v, got %v", expected, got)
+			}
+		})
+	}
+
+	if _, err := LoadRecipients(strings.NewReader(""), "xml"); err != ErrInvalidRecipientsFormat {
+		t.Errorf("expected %v, got %v", ErrInvalidRecipientsFormat, err)
+	}
+}
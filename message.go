@@ -2,13 +2,25 @@ package pushover
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
 	"io"
+	"math"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode/utf8"
 )
@@ -19,6 +31,28 @@ func init() {
 	deviceNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]{1,25}$`)
 }
 
+// quoteEscaper escapes the quotes of the multipart header values.
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// DeviceAll can be used as the device name of a message to send it to all the
+// devices of the recipient, which is the default. It's the message equivalent
+// of GlancesAllDevices.
+const DeviceAll = ""
+
+// DeviceSeparator separates the device names when a message or a glance is
+// sent to multiple devices, see JoinDevices.
+const DeviceSeparator = ","
+
+// MessageFormat represents the way the text of a message is displayed.
+type MessageFormat int
+
+// Message formats
+const (
+	FormatPlain MessageFormat = iota
+	FormatHTML
+	FormatMonospace
+)
+
 // Message represents a pushover message.
 type Message struct {
 	// Required
@@ -26,7 +60,7 @@ type Message struct {
 
 	// Optional
 	Title       string
-	Priority    int
+	Priority    Priority
 	URL         string
 	URLTitle    string
 	Timestamp   int64
@@ -39,8 +73,164 @@ type Message struct {
 	Monospace   bool
 	TTL         time.Duration
 
+	// Devices are the devices to send the message to, they take precedence
+	// over the DeviceName if not empty.
+	Devices []string
+
+	// Tags are attached to the emergency messages, they can be canceled by
+	// tag with Pushover.CancelEmergencyNotificationByTag.
+	Tags []string
+
+	// BestEffort messages are skipped instead of being sent when the
+	// remaining quota is low, see Pushover.SetQuotaReserve.
+	BestEffort bool
+
+	// AttachmentType is the MIME type of the attachment, it's detected from
+	// the attachment content if empty.
+	AttachmentType string
+
+	// AllowCustomSound allows the sounds uploaded by the users, otherwise
+	// the sound must be one of the Sound constants.
+	AllowCustomSound bool
+
+	// AttachmentBase64 sends the attachment base64 encoded in a URL encoded
+	// request instead of a multipart one. The size limit of the attachment is
+	// MessageMaxAttachmentBase64Byte to account for the encoding overhead.
+	AttachmentBase64 bool
+
 	// attachment
-	attachment io.Reader
+	attachment      io.Reader
+	attachmentCache *attachmentCache
+	attachmentName  string
+
+	// skipLengthLimits disables the length validations
+	skipLengthLimits bool
+}
+
+// MessageOption represents an option used to configure a message.
+type MessageOption func(*Message)
+
+// WithoutLengthLimits disables the length validations of the message, the
+// other validations are kept. This is useful when the messages are sent to a
+// gateway accepting longer messages, the pushover API will reject them.
+func WithoutLengthLimits() MessageOption {
+	return func(m *Message) {
+		m.skipLengthLimits = true
+	}
+}
+
+// WithTitle sets the title of the message.
+func WithTitle(title string) MessageOption {
+	return func(m *Message) {
+		m.Title = title
+	}
+}
+
+// WithPriority sets the priority of the message, see WithEmergency for the
+// emergency priority.
+func WithPriority(priority Priority) MessageOption {
+	return func(m *Message) {
+		m.Priority = priority
+	}
+}
+
+// WithSound sets the sound of the message.
+func WithSound(sound string) MessageOption {
+	return func(m *Message) {
+		m.Sound = sound
+	}
+}
+
+// WithCustomSounds allows the sounds uploaded by the users, see
+// AllowCustomSound.
+func WithCustomSounds() MessageOption {
+	return func(m *Message) {
+		m.AllowCustomSound = true
+	}
+}
+
+// WithURL sets the supplementary URL of the message along with its title,
+// which can be empty.
+func WithURL(url, title string) MessageOption {
+	return func(m *Message) {
+		m.URL = url
+		m.URLTitle = title
+	}
+}
+
+// WithDevice sets the device name of the message.
+func WithDevice(device string) MessageOption {
+	return func(m *Message) {
+		m.DeviceName = device
+	}
+}
+
+// WithHTML enables the HTML formatting of the message.
+func WithHTML() MessageOption {
+	return func(m *Message) {
+		m.HTML = true
+	}
+}
+
+// WithEmergency sets the emergency priority of the message along with its
+// retry and expire durations.
+func WithEmergency(retry, expire time.Duration) MessageOption {
+	return func(m *Message) {
+		m.Priority = PriorityEmergency
+		m.Retry = retry
+		m.Expire = expire
+	}
+}
+
+// Apply applies the options to the message and returns it.
+func (m *Message) Apply(opts ...MessageOption) *Message {
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// attachmentCache keeps the content of an attachment once it has been read,
+// it's shared by the copies of a message so the attachment is read only once.
+type attachmentCache struct {
+	mu   sync.Mutex
+	done bool
+	data []byte
+}
+
+// load reads the attachment until it succeeds and returns the cached content
+// afterwards. A failed read is not cached, the next call resumes the read
+// where it stopped. The read stops with ErrMessageAttachmentTooLarge as soon
+// as the attachment exceeds the size limit.
+func (c *attachmentCache) load(r io.Reader, bufferSize int) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.done {
+		return c.data, nil
+	}
+
+	if bufferSize <= 0 {
+		bufferSize = DefaultUploadBufferSize
+	}
+
+	// Hide the ReaderFrom implementation of the buffer so the copy buffer is
+	// used
+	buf := bytes.NewBuffer(c.data)
+	r = io.LimitReader(r, int64(MessageMaxAttachmentByte+1-len(c.data)))
+	_, err := io.CopyBuffer(struct{ io.Writer }{buf}, r, make([]byte, bufferSize))
+	c.data = buf.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(c.data) > MessageMaxAttachmentByte {
+		return nil, ErrMessageAttachmentTooLarge
+	}
+
+	c.done = true
+	return c.data, nil
 }
 
 // NewMessage returns a simple new message.
@@ -53,78 +243,300 @@ func NewMessageWithTitle(message, title string) *Message {
 	return &Message{Message: message, Title: title}
 }
 
+// NewMessageWith returns a new message configured with the options.
+func NewMessageWith(message string, opts ...MessageOption) *Message {
+	return NewMessage(message).Apply(opts...)
+}
+
+// NewEmergencyMessage returns a new message with an emergency priority, it's
+// sent again every retry until it's acknowledged or expires.
+func NewEmergencyMessage(message string, retry, expire time.Duration) *Message {
+	return &Message{
+		Message:  message,
+		Priority: PriorityEmergency,
+		Retry:    retry,
+		Expire:   expire,
+	}
+}
+
+// SetTimestamp sets the timestamp of the message displayed instead of the
+// time the message was received.
+func (m *Message) SetTimestamp(t time.Time) {
+	m.Timestamp = t.Unix()
+}
+
+// messageSummaryLength is the max number of characters of the texts in the
+// summary of a message.
+const messageSummaryLength = 50
+
+// String returns a short summary of the message for logging purposes, the
+// texts are truncated and the attachment content is not included.
+func (m *Message) String() string {
+	device := m.device()
+	if device == DeviceAll {
+		device = "all"
+	}
+
+	return fmt.Sprintf("title=%q message=%q priority=%s device=%s sound=%q attachment=%t",
+		truncate(m.Title, messageSummaryLength),
+		truncate(m.Message, messageSummaryLength),
+		m.Priority, device, m.Sound, m.attachment != nil)
+}
+
+// truncate returns the first n characters of a string followed by an
+// ellipsis if it's longer.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	return string([]rune(s)[:n]) + "…"
+}
+
+// Hash returns a stable key of the content of the message which can be used
+// to deduplicate messages. It's the hex encoded SHA-256 of the message,
+// title, priority, URL, URL title, sound and format, the recipient specific
+// fields such as the device name and the attachment are not used.
+func (m *Message) Hash() string {
+	fields := []string{
+		m.Message,
+		m.Title,
+		strconv.Itoa(int(m.Priority)),
+		m.URL,
+		m.URLTitle,
+		m.Sound,
+		strconv.FormatBool(m.HTML),
+		strconv.FormatBool(m.Monospace),
+	}
+
+	// Quote the fields so the separators can't be forged
+	h := sha256.New()
+	for _, f := range fields {
+		io.WriteString(h, strconv.Quote(f))
+		io.WriteString(h, "\n")
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 // AddAttachment adds an attachment to the message it's programmer's
-// responsibility to close the reader.
+// responsibility to close the reader. The attachment is read once and kept in
+// memory so the message can be sent several times. The file name and the MIME
+// type of a previous attachment are reset.
 func (m *Message) AddAttachment(attachment io.Reader) error {
 	m.attachment = attachment
+	m.attachmentCache = &attachmentCache{}
+	m.attachmentName = ""
+	m.AttachmentType = ""
 	return nil
 }
 
+// AddAttachmentReader adds an attachment to the message, it's the same as
+// WithAttachment with the file name and MIME type first.
+func (m *Message) AddAttachmentReader(name, contentType string, r io.Reader) error {
+	return m.WithAttachment(r, name, contentType)
+}
+
+// WithAttachment reads and buffers the attachment of the message along with
+// its file name and MIME type, the type is detected from the content if
+// empty. ErrMessageAttachmentTooLarge is returned as soon as the size limit
+// is exceeded, without reading the rest of the reader.
+func (m *Message) WithAttachment(r io.Reader, filename, contentType string) error {
+	buf := &bytes.Buffer{}
+	if _, err := io.Copy(buf, io.LimitReader(r, MessageMaxAttachmentByte+1)); err != nil {
+		return err
+	}
+
+	if buf.Len() > MessageMaxAttachmentByte {
+		return ErrMessageAttachmentTooLarge
+	}
+
+	if err := m.AddAttachment(buf); err != nil {
+		return err
+	}
+	m.attachmentName = filename
+	m.AttachmentType = contentType
+
+	return nil
+}
+
+// AddAttachmentImage encodes the image using the given format and adds it as
+// an attachment to the message. The supported formats are "png" and "jpeg".
+func (m *Message) AddAttachmentImage(img image.Image, format string) error {
+	buf := &bytes.Buffer{}
+
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(buf, img)
+	case "jpeg", "jpg":
+		err = jpeg.Encode(buf, img, nil)
+	default:
+		return ErrInvalidImageFormat
+	}
+	if err != nil {
+		return err
+	}
+
+	if buf.Len() > MessageMaxAttachmentByte {
+		return ErrMessageAttachmentTooLarge
+	}
+
+	return m.AddAttachment(buf)
+}
+
 // Validate the message values.
 func (m *Message) validate() error {
+	if errs := m.ValidateAll(); len(errs) > 0 {
+		return errs[0]
+	}
+
+	return nil
+}
+
+// ValidateAll validates the message and returns all the validation errors,
+// the slice is empty if the message is valid.
+func (m *Message) ValidateAll() []error {
+	var errs []error
+
 	// Message should no be empty
 	if m.Message == "" {
-		return ErrMessageEmpty
+		errs = append(errs, ErrMessageEmpty)
 	}
 
-	// Validate message length
-	if utf8.RuneCountInString(m.Message) > MessageMaxLength {
-		return ErrMessageTooLong
+	if !m.skipLengthLimits {
+		// Validate message length
+		if err := checkLength("message", m.Message, MessageMaxLength, ErrMessageTooLong); err != nil {
+			errs = append(errs, err)
+		}
+
+		// Validate Title field length
+		if err := checkLength("title", m.Title, MessageTitleMaxLength, ErrMessageTitleTooLong); err != nil {
+			errs = append(errs, err)
+		}
+
+		// Validate URL field
+		if err := checkLength("url", m.URL, MessageURLMaxLength, ErrMessageURLTooLong); err != nil {
+			errs = append(errs, err)
+		}
+
+		// Validate URL title field
+		if err := checkLength("url_title", m.URLTitle, MessageURLTitleMaxLength, ErrMessageURLTitleTooLong); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// URLTitle should not be set with an empty URL
+	if m.URL == "" && m.URLTitle != "" {
+		errs = append(errs, ErrEmptyURL)
 	}
 
-	// Validate Title field length
-	if utf8.RuneCountInString(m.Title) > MessageTitleMaxLength {
-		return ErrMessageTitleTooLong
+	// Check the size of the in-memory attachments, the size of the streamed
+	// ones is checked while they are read
+	if l, ok := m.attachment.(interface{ Len() int }); ok && l.Len() > MessageMaxAttachmentByte {
+		errs = append(errs, ErrMessageAttachmentTooLarge)
 	}
 
-	// Validate URL field
-	if utf8.RuneCountInString(m.URL) > MessageURLMaxLength {
-		return ErrMessageURLTooLong
+	// The API ignores the TTL of the emergency messages
+	if m.TTL != 0 && m.Priority == PriorityEmergency {
+		errs = append(errs, ErrTTLWithEmergency)
 	}
 
-	// Validate URL title field
-	if utf8.RuneCountInString(m.URLTitle) > MessageURLTitleMaxLength {
-		return ErrMessageURLTitleTooLong
+	// Validate the sound
+	if m.Sound != "" && !m.AllowCustomSound && !builtinSounds[m.Sound] {
+		errs = append(errs, ErrInvalidSound)
 	}
 
-	// URLTitle should not be set with an empty URL
-	if m.URL == "" && m.URLTitle != "" {
-		return ErrEmptyURL
+	// The API rejects the messages using both formats
+	if m.HTML && m.Monospace {
+		errs = append(errs, ErrMonospaceHTMLExclusive)
 	}
 
 	// Validate priorities
 	if m.Priority > PriorityEmergency || m.Priority < PriorityLowest {
-		return ErrInvalidPriority
+		errs = append(errs, ErrInvalidPriority)
 	}
 
 	// Validate emergency priority
 	if m.Priority == PriorityEmergency {
 		if m.Retry == 0 || m.Expire == 0 {
-			return ErrMissingEmergencyParameter
+			errs = append(errs, ErrMissingEmergencyParameter)
+		} else {
+			if m.Retry < MessageMinRetry {
+				errs = append(errs, ErrEmergencyRetryTooShort)
+			}
+
+			if m.Expire > MessageMaxExpire {
+				errs = append(errs, ErrEmergencyExpireTooLong)
+			}
 		}
 	}
 
 	// Test device name
-	if m.DeviceName != "" {
-		// Accept comma separated device names
-		devices := strings.Split(m.DeviceName, ",")
-		for _, d := range devices {
-			if !deviceNameRegexp.MatchString(d) {
-				return ErrInvalidDeviceName
-			}
+	if len(m.Devices) > 0 {
+		if _, err := JoinDevices(m.Devices...); err != nil {
+			errs = append(errs, err)
 		}
+	} else if err := validateDeviceNames(m.DeviceName); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// device returns the devices of the message separated by DeviceSeparator,
+// the Devices are used if not empty.
+func (m *Message) device() string {
+	if len(m.Devices) > 0 {
+		return strings.Join(m.Devices, DeviceSeparator)
+	}
+
+	return m.DeviceName
+}
+
+// forRecipient returns a copy of the message to send to the given recipient,
+// the values depending on the recipient are set on the copy.
+func (m *Message) forRecipient(recipient *Recipient) *Message {
+	msg := *m
+
+	if msg.device() == DeviceAll && recipient.device != "" {
+		msg.DeviceName = recipient.device
+	}
+
+	return &msg
+}
+
+// validateCallbackURL checks that the callback is an absolute HTTP URL.
+func validateCallbackURL(callback string) error {
+	u, err := url.Parse(callback)
+	if err != nil {
+		return ErrInvalidCallbackURL
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidCallbackURL
 	}
 
 	return nil
 }
 
+// Params returns the parameters posted to the API to send the message, without
+// the app token and the recipient key added to the request. The values
+// depending on the recipient, such as its default device, are not applied.
+func (m *Message) Params() map[string]string {
+	params := m.toMap("", "")
+	delete(params, "token")
+	delete(params, "user")
+	return params
+}
+
 // Return a map filled with the relevant data.
 func (m *Message) toMap(pToken, rToken string) map[string]string {
 	ret := map[string]string{
 		"token":    pToken,
 		"user":     rToken,
 		"message":  m.Message,
-		"priority": strconv.Itoa(m.Priority),
+		"priority": strconv.Itoa(int(m.Priority)),
 	}
 
 	if m.Title != "" {
@@ -143,8 +555,8 @@ func (m *Message) toMap(pToken, rToken string) map[string]string {
 		ret["sound"] = m.Sound
 	}
 
-	if m.DeviceName != "" {
-		ret["device"] = m.DeviceName
+	if device := m.device(); device != "" {
+		ret["device"] = device
 	}
 
 	if m.Timestamp != 0 {
@@ -167,42 +579,233 @@ func (m *Message) toMap(pToken, rToken string) map[string]string {
 		}
 	}
 
-	if m.TTL != 0 {
-		ret["ttl"] = strconv.FormatFloat(m.TTL.Seconds(), 'f', -1, 64)
+	// The TTL is a number of seconds, rounded up
+	if m.TTL > 0 {
+		ret["ttl"] = strconv.FormatInt(int64((m.TTL+time.Second-1)/time.Second), 10)
+	}
+
+	if len(m.Tags) > 0 {
+		ret["tags"] = strings.Join(m.Tags, ",")
 	}
 
 	return ret
 }
 
-// Send sends the message using the pushover and the recipient tokens.
-func (m *Message) send(pToken, rToken string) (*Response, error) {
-	url := fmt.Sprintf("%s/messages.json", APIEndpoint)
+// MessageFromMap returns a message from a map using the same keys as the
+// ones sent to the API. The token and user keys are ignored. The options are
+// applied before the validation of the message, e.g. WithCustomSounds.
+func MessageFromMap(params map[string]string, opts ...MessageOption) (*Message, error) {
+	m := &Message{
+		Message:     params["message"],
+		Title:       params["title"],
+		URL:         params["url"],
+		URLTitle:    params["url_title"],
+		CallbackURL: params["callback"],
+		DeviceName:  params["device"],
+		Sound:       params["sound"],
+	}
+
+	if v := params["tags"]; v != "" {
+		m.Tags = strings.Split(v, ",")
+	}
 
-	var f func(string, string, string) (*http.Request, error)
+	var err error
+	if v, ok := params["priority"]; ok {
+		priority, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("pushover: invalid priority: %w", err)
+		}
+		m.Priority = Priority(priority)
+	}
+
+	if v, ok := params["timestamp"]; ok {
+		if m.Timestamp, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, fmt.Errorf("pushover: invalid timestamp: %w", err)
+		}
+	}
+
+	durations := map[string]*time.Duration{
+		"retry":  &m.Retry,
+		"expire": &m.Expire,
+		"ttl":    &m.TTL,
+	}
+	for key, d := range durations {
+		v, ok := params[key]
+		if !ok {
+			continue
+		}
+
+		seconds, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("pushover: invalid %s: %w", key, err)
+		}
+		*d = time.Duration(seconds * float64(time.Second))
+	}
+
+	flags := map[string]*bool{
+		"html":      &m.HTML,
+		"monospace": &m.Monospace,
+	}
+	for key, b := range flags {
+		switch params[key] {
+		case "", "0":
+			*b = false
+		case "1":
+			*b = true
+		default:
+			return nil, fmt.Errorf("pushover: invalid %s flag: %q", key, params[key])
+		}
+	}
+
+	m.Apply(opts...)
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// messageJSON is the JSON representation of a message, the retry, expire and
+// TTL durations are numbers of seconds like in the API.
+type messageJSON struct {
+	Message          string   `json:"message"`
+	Title            string   `json:"title,omitempty"`
+	Priority         Priority `json:"priority"`
+	URL              string   `json:"url,omitempty"`
+	URLTitle         string   `json:"url_title,omitempty"`
+	Timestamp        int64    `json:"timestamp,omitempty"`
+	Retry            float64  `json:"retry,omitempty"`
+	Expire           float64  `json:"expire,omitempty"`
+	CallbackURL      string   `json:"callback,omitempty"`
+	DeviceName       string   `json:"device,omitempty"`
+	Devices          []string `json:"devices,omitempty"`
+	Sound            string   `json:"sound,omitempty"`
+	HTML             bool     `json:"html,omitempty"`
+	Monospace        bool     `json:"monospace,omitempty"`
+	TTL              float64  `json:"ttl,omitempty"`
+	Tags             []string `json:"tags,omitempty"`
+	BestEffort       bool     `json:"best_effort,omitempty"`
+	AttachmentType   string   `json:"attachment_type,omitempty"`
+	AllowCustomSound bool     `json:"allow_custom_sound,omitempty"`
+	AttachmentBase64 bool     `json:"attachment_base64,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface, the retry, expire and
+// TTL durations are encoded as numbers of seconds. The attachment of the
+// message is not encoded.
+func (m Message) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&messageJSON{
+		Message:          m.Message,
+		Title:            m.Title,
+		Priority:         m.Priority,
+		URL:              m.URL,
+		URLTitle:         m.URLTitle,
+		Timestamp:        m.Timestamp,
+		Retry:            m.Retry.Seconds(),
+		Expire:           m.Expire.Seconds(),
+		CallbackURL:      m.CallbackURL,
+		DeviceName:       m.DeviceName,
+		Devices:          m.Devices,
+		Sound:            m.Sound,
+		HTML:             m.HTML,
+		Monospace:        m.Monospace,
+		TTL:              m.TTL.Seconds(),
+		Tags:             m.Tags,
+		BestEffort:       m.BestEffort,
+		AttachmentType:   m.AttachmentType,
+		AllowCustomSound: m.AllowCustomSound,
+		AttachmentBase64: m.AttachmentBase64,
+	})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, see MarshalJSON.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var aux messageJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*m = Message{
+		Message:          aux.Message,
+		Title:            aux.Title,
+		Priority:         aux.Priority,
+		URL:              aux.URL,
+		URLTitle:         aux.URLTitle,
+		Timestamp:        aux.Timestamp,
+		Retry:            jsonDuration(aux.Retry),
+		Expire:           jsonDuration(aux.Expire),
+		CallbackURL:      aux.CallbackURL,
+		DeviceName:       aux.DeviceName,
+		Devices:          aux.Devices,
+		Sound:            aux.Sound,
+		HTML:             aux.HTML,
+		Monospace:        aux.Monospace,
+		TTL:              jsonDuration(aux.TTL),
+		Tags:             aux.Tags,
+		BestEffort:       aux.BestEffort,
+		AttachmentType:   aux.AttachmentType,
+		AllowCustomSound: aux.AllowCustomSound,
+		AttachmentBase64: aux.AttachmentBase64,
+	}
+
+	return nil
+}
+
+// jsonDuration returns the duration of a number of seconds decoded from JSON.
+func jsonDuration(seconds float64) time.Duration {
+	return time.Duration(math.Round(seconds * float64(time.Second)))
+}
+
+// request returns the request to send the message to the API endpoint using
+// the pushover and the recipient tokens. The buffer size is used to copy the
+// attachment.
+func (m *Message) request(endpoint, pToken, rToken string, bufferSize int) (*http.Request, error) {
+	url := fmt.Sprintf("%s/messages.json", endpoint)
+	return m.requestURL(pToken, rToken, url, bufferSize)
+}
+
+// requestURL returns the request to send the message to the given URL.
+func (m *Message) requestURL(pToken, rToken, url string, bufferSize int) (*http.Request, error) {
 	if m.attachment == nil {
 		// Use a URL-encoded request if there's no need to attach files
-		f = m.urlEncodedRequest
-	} else {
-		// Use a multipart request if a file should be sent
-		f = m.multipartRequest
+		return m.urlEncodedRequest(pToken, rToken, url)
 	}
 
-	// Post the from and check the headers of the response
-	req, err := f(pToken, rToken, url)
-	if err != nil {
+	if m.AttachmentBase64 {
+		return m.base64Request(pToken, rToken, url, bufferSize)
+	}
+
+	// Use a multipart request if a file should be sent
+	return m.multipartRequest(pToken, rToken, url, bufferSize)
+}
+
+// BuildRequest returns the request that would be used to send the message
+// with the given tokens to the given URL, the messages endpoint is used if
+// the URL is empty. DefaultAPIEndpoint is used since the message is not
+// bound to an app, whatever the value of APIEndpoint. The request can be inspected or modified before being
+// sent with a custom client.
+func (m *Message) BuildRequest(ctx context.Context, appToken, userToken, url string) (*http.Request, error) {
+	if err := m.validate(); err != nil {
 		return nil, err
 	}
 
-	resp := &Response{}
-	if err := do(req, resp, true); err != nil {
+	if url == "" {
+		url = fmt.Sprintf("%s/messages.json", DefaultAPIEndpoint)
+	}
+
+	req, err := m.requestURL(appToken, userToken, url, DefaultUploadBufferSize)
+	if err != nil {
 		return nil, err
 	}
 
-	return resp, nil
+	return req.WithContext(ctx), nil
 }
 
-// multipartRequest returns a new multipart POST request with a file attached.
-func (m *Message) multipartRequest(pToken, rToken, url string) (*http.Request, error) {
+// multipartRequest returns a new multipart POST request with a file attached,
+// its ContentLength is the total size of the body.
+// The attachment is copied using a buffer of the given size, the default size
+// is used if it's not positive.
+func (m *Message) multipartRequest(pToken, rToken, url string, bufferSize int) (*http.Request, error) {
 	body := &bytes.Buffer{}
 
 	if m.attachment == nil {
@@ -212,23 +815,50 @@ func (m *Message) multipartRequest(pToken, rToken, url string) (*http.Request, e
 	// Write the body as multipart form data
 	w := multipart.NewWriter(body)
 
-	// Write the file in the body
-	fw, err := w.CreateFormFile("attachment", "attachment")
+	cache := m.attachmentCache
+	if cache == nil {
+		cache = &attachmentCache{}
+	}
+
+	data, err := cache.load(m.attachment, bufferSize)
 	if err != nil {
 		return nil, err
 	}
 
-	written, err := io.Copy(fw, m.attachment)
+	if len(data) > MessageMaxAttachmentByte {
+		return nil, ErrMessageAttachmentTooLarge
+	}
+
+	contentType := m.AttachmentType
+	if contentType == "" {
+		// Only the first 512 bytes are considered
+		contentType = http.DetectContentType(data)
+	}
+
+	filename := m.attachmentName
+	if filename == "" {
+		filename = "attachment"
+	}
+
+	// Write the file in the body
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="attachment"; filename="%s"`,
+		quoteEscaper.Replace(filename)))
+	h.Set("Content-Type", contentType)
+	fw, err := w.CreatePart(h)
 	if err != nil {
 		return nil, err
 	}
 
-	if written > MessageMaxAttachmentByte {
-		return nil, ErrMessageAttachmentTooLarge
+	if _, err := fw.Write(data); err != nil {
+		return nil, err
 	}
 
+	params := m.toMap(pToken, rToken)
+	params["attachment_type"] = contentType
+
 	// Handle params
-	for k, v := range m.toMap(pToken, rToken) {
+	for k, v := range params {
 		if err := w.WriteField(k, v); err != nil {
 			return nil, err
 		}
@@ -247,6 +877,33 @@ func (m *Message) multipartRequest(pToken, rToken, url string) (*http.Request, e
 	return req, nil
 }
 
+// base64Request returns a new URL encoded request with the attachment base64
+// encoded.
+func (m *Message) base64Request(pToken, rToken, url string, bufferSize int) (*http.Request, error) {
+	cache := m.attachmentCache
+	if cache == nil {
+		cache = &attachmentCache{}
+	}
+
+	data, err := cache.load(m.attachment, bufferSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > MessageMaxAttachmentBase64Byte {
+		return nil, ErrMessageAttachmentTooLarge
+	}
+
+	params := m.toMap(pToken, rToken)
+	params["attachment_base64"] = base64.StdEncoding.EncodeToString(data)
+	params["attachment_type"] = m.AttachmentType
+	if m.AttachmentType == "" {
+		params["attachment_type"] = http.DetectContentType(data)
+	}
+
+	return newURLEncodedRequest("POST", url, params)
+}
+
 // urlEncodedRequest returns a new url encoded request.
 func (m *Message) urlEncodedRequest(pToken, rToken, endpoint string) (*http.Request, error) {
 	return newURLEncodedRequest("POST", endpoint, m.toMap(pToken, rToken))
@@ -2,9 +2,16 @@ package pushover
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"image"
+	"io"
 	"math/rand"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 	"unicode/utf8"
@@ -164,7 +171,7 @@ func TestMessageValidation(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			if err := tc.message.validate(); err != tc.expectedErr {
+			if err := tc.message.validate(); !errors.Is(err, tc.expectedErr) {
 				t.Errorf("expected %v; got %v", tc.expectedErr, err)
 			}
 		})
@@ -183,6 +190,7 @@ func TestMessageDeviceName(t *testing.T) {
 		{"good device name 2", "fasdfafdadfasdfa", nil},
 		{"multiple devices", "device1,device2", nil},
 		{"invalid multiple devices", "device1,device^2", ErrInvalidDeviceName},
+		{"trailing separator", "device1,", ErrInvalidDeviceName},
 		{"invalid device name 1", "yo&mama", ErrInvalidDeviceName},
 		{"invalid device name 2", "my^device", ErrInvalidDeviceName},
 		{"invalid device name 3", "d34342fasdfasdfasdfasdfasdfasd", ErrInvalidDeviceName},
@@ -201,6 +209,71 @@ func TestMessageDeviceName(t *testing.T) {
 	}
 }
 
+// TestMessageDevices tests the devices of the message are validated and
+// joined
+func TestMessageDevices(t *testing.T) {
+	tt := []struct {
+		name       string
+		devices    []string
+		deviceName string
+		expected   string
+		err        error
+	}{
+		{"single device", []string{"device1"}, "", "device1", nil},
+		{"multiple devices", []string{"device1", "device2"}, "", "device1,device2", nil},
+		{"one invalid device", []string{"device1", "my^device", "device2"}, "", "", ErrInvalidDeviceName},
+		{"device with separator", []string{"device1,device2"}, "", "", ErrDeviceNameSeparator},
+		{"precedence over the device name", []string{"device1"}, "device2", "device1", nil},
+		{"device name", nil, "device2", "device2", nil},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			message := &Message{Message: "Hello", Devices: tc.devices, DeviceName: tc.deviceName}
+			if err := message.validate(); err != tc.err {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+
+			if tc.err != nil {
+				return
+			}
+
+			if got := message.toMap("pToken", "rToken")["device"]; got != tc.expected {
+				t.Errorf("expected device %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestJoinDevices tests the device names are joined with the separator
+func TestJoinDevices(t *testing.T) {
+	tt := []struct {
+		name     string
+		devices  []string
+		expected string
+		err      error
+	}{
+		{"single device", []string{"device1"}, "device1", nil},
+		{"multiple devices", []string{"device1", "device2"}, "device1,device2", nil},
+		{"separator", []string{"device1", "device2,device3"}, "", ErrDeviceNameSeparator},
+		{"invalid device", []string{"device1", "my^device"}, "", ErrInvalidDeviceName},
+		{"empty device", []string{"device1", ""}, "", ErrInvalidDeviceName},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := JoinDevices(tc.devices...)
+			if err != tc.err {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+
+			if got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
 // TestNewMessageWithTitle
 func TestNewMessageWithTitle(t *testing.T) {
 	message := NewMessageWithTitle("World", "Hello")
@@ -215,6 +288,68 @@ func TestNewMessageWithTitle(t *testing.T) {
 	}
 }
 
+// TestNewMessageWith tests the options are applied to the message
+func TestNewMessageWith(t *testing.T) {
+	tt := []struct {
+		name     string
+		opts     []MessageOption
+		expected *Message
+	}{
+		{
+			name:     "no options",
+			expected: &Message{Message: "Hello"},
+		},
+		{
+			name: "several options",
+			opts: []MessageOption{
+				WithTitle("Title"),
+				WithPriority(PriorityHigh),
+				WithSound(SoundCosmic),
+				WithURL("http://google.com", "Google"),
+				WithDevice("phone"),
+				WithHTML(),
+			},
+			expected: &Message{
+				Message:    "Hello",
+				Title:      "Title",
+				Priority:   PriorityHigh,
+				Sound:      SoundCosmic,
+				URL:        "http://google.com",
+				URLTitle:   "Google",
+				DeviceName: "phone",
+				HTML:       true,
+			},
+		},
+		{
+			name: "emergency",
+			opts: []MessageOption{
+				WithTitle("Title"),
+				WithEmergency(time.Minute, time.Hour),
+			},
+			expected: &Message{
+				Message:  "Hello",
+				Title:    "Title",
+				Priority: PriorityEmergency,
+				Retry:    time.Minute,
+				Expire:   time.Hour,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			message := NewMessageWith("Hello", tc.opts...)
+			if !reflect.DeepEqual(message, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, message)
+			}
+
+			if err := message.validate(); err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
 // MultipartRequest
 func TestMultipartRequest(t *testing.T) {
 	tt := []struct {
@@ -248,7 +383,7 @@ func TestMultipartRequest(t *testing.T) {
 				message.AddAttachment(attachment)
 			}
 
-			req, err := message.multipartRequest("pToken", "rToken", "url")
+			req, err := message.multipartRequest("pToken", "rToken", "url", 0)
 			if err != tc.expectedErr {
 				t.Fatalf("expected %q, got %q", tc.expectedErr, err)
 			}
@@ -262,11 +397,12 @@ func TestMultipartRequest(t *testing.T) {
 			}
 
 			expectedValues := map[string][]string{
-				"token":    {"pToken"},
-				"user":     {"rToken"},
-				"message":  {"World"},
-				"priority": {"0"},
-				"title":    {"Hello"},
+				"token":           {"pToken"},
+				"user":            {"rToken"},
+				"message":         {"World"},
+				"priority":        {"0"},
+				"title":           {"Hello"},
+				"attachment_type": {"application/octet-stream"},
 			}
 
 			if !reflect.DeepEqual(req.MultipartForm.Value, expectedValues) {
@@ -293,3 +429,925 @@ func TestMultipartRequest(t *testing.T) {
 		})
 	}
 }
+
+// TestMessageFromMap tests that a message can be rebuilt from its encoded
+// form
+func TestMessageFromMap(t *testing.T) {
+	messages := []*Message{
+		{
+			Message:     "My awesome message",
+			Title:       "My title",
+			Priority:    PriorityEmergency,
+			URL:         "http://google.com",
+			URLTitle:    "Google",
+			Timestamp:   time.Now().Unix(),
+			Retry:       60 * time.Second,
+			Expire:      time.Hour,
+			DeviceName:  "SuperDevice",
+			CallbackURL: "http://yourapp.com/callback",
+			Sound:       SoundCosmic,
+			HTML:        true,
+			Tags:        []string{"incident-42", "db"},
+		},
+		{
+			Message:  "My awesome message",
+			Priority: PriorityHigh,
+			TTL:      90 * time.Second,
+		},
+	}
+
+	for _, message := range messages {
+		got, err := MessageFromMap(message.toMap("pToken", "rToken"))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if !reflect.DeepEqual(got, message) {
+			t.Errorf("invalid message from map\nexpected: %+v\ngot: %+v", message, got)
+		}
+	}
+
+	tt := []struct {
+		name   string
+		params map[string]string
+	}{
+		{"invalid priority", map[string]string{"message": "hello", "priority": "high"}},
+		{"invalid retry", map[string]string{"message": "hello", "retry": "1m"}},
+		{"invalid html flag", map[string]string{"message": "hello", "html": "true"}},
+		{"invalid message", map[string]string{"priority": "0"}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := MessageFromMap(tc.params); err == nil {
+				t.Fatalf("expected an error, got nil")
+			}
+		})
+	}
+}
+
+// TestMessageFromMapCustomSound tests the sounds uploaded by the users are
+// accepted with WithCustomSounds
+func TestMessageFromMapCustomSound(t *testing.T) {
+	params := map[string]string{"message": "hello", "sound": "my_sound"}
+	if _, err := MessageFromMap(params); err != ErrInvalidSound {
+		t.Fatalf("expected %v, got %v", ErrInvalidSound, err)
+	}
+
+	got, err := MessageFromMap(params, WithCustomSounds())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if got.Sound != "my_sound" || !got.AllowCustomSound {
+		t.Errorf("expected the custom sound to be allowed, got %+v", got)
+	}
+}
+
+// TestAddAttachmentImage tests the image encoding of attachments
+func TestAddAttachmentImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+
+	tt := []struct {
+		name         string
+		format       string
+		expectedType string
+		expectedErr  error
+	}{
+		{"png image", "png", "image/png", nil},
+		{"jpeg image", "jpeg", "image/jpeg", nil},
+		{"invalid format", "gif", "", ErrInvalidImageFormat},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			message := NewMessage("Hello")
+			if err := message.AddAttachmentImage(img, tc.format); err != tc.expectedErr {
+				t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if tc.expectedErr != nil {
+				return
+			}
+
+			req, err := message.multipartRequest("pToken", "rToken", "url", 0)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if err := req.ParseMultipartForm(MessageMaxAttachmentByte); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if got := req.FormValue("attachment_type"); got != tc.expectedType {
+				t.Errorf("expected attachment type %q, got %q", tc.expectedType, got)
+			}
+		})
+	}
+}
+
+// TestAttachmentTypeOverride tests that the attachment type can be set
+// explicitly
+func TestAttachmentTypeOverride(t *testing.T) {
+	message := NewMessage("Hello")
+	message.AttachmentType = "image/gif"
+	message.AddAttachment(bytes.NewBufferString("GIF89a"))
+
+	req, err := message.multipartRequest("pToken", "rToken", "url", 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := req.ParseMultipartForm(1024); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if got := req.FormValue("attachment_type"); got != "image/gif" {
+		t.Errorf("expected attachment type %q, got %q", "image/gif", got)
+	}
+}
+
+// flakyReader fails its first read.
+type flakyReader struct {
+	failed bool
+	r      io.Reader
+}
+
+func (f *flakyReader) Read(p []byte) (int, error) {
+	if !f.failed {
+		f.failed = true
+		return 0, errors.New("temporary failure")
+	}
+
+	return f.r.Read(p)
+}
+
+// TestAttachmentCacheFailure tests a failed read of the attachment is not
+// cached and the next read resumes where it stopped
+func TestAttachmentCacheFailure(t *testing.T) {
+	cache := &attachmentCache{}
+	r := io.MultiReader(strings.NewReader("da"), &flakyReader{r: strings.NewReader("ta")})
+
+	if _, err := cache.load(r, 0); err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+
+	for i := 0; i < 2; i++ {
+		data, err := cache.load(r, 0)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if string(data) != "data" {
+			t.Errorf("expected %q, got %q", "data", data)
+		}
+	}
+}
+
+// TestWithAttachment tests the attachment file name and type are sent
+func TestWithAttachment(t *testing.T) {
+	message := NewMessage("Hello")
+	if err := message.WithAttachment(strings.NewReader("a,b"), "report.csv", "text/csv"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	req, err := message.multipartRequest("pToken", "rToken", "url", 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := req.ParseMultipartForm(1024); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	files := req.MultipartForm.File["attachment"]
+	if len(files) != 1 {
+		t.Fatalf("expected 1 attachment, got %d", len(files))
+	}
+
+	if files[0].Filename != "report.csv" {
+		t.Errorf("expected file name %q, got %q", "report.csv", files[0].Filename)
+	}
+
+	if got := files[0].Header.Get("Content-Type"); got != "text/csv" {
+		t.Errorf("expected content type %q, got %q", "text/csv", got)
+	}
+
+	if got := req.FormValue("attachment_type"); got != "text/csv" {
+		t.Errorf("expected attachment type %q, got %q", "text/csv", got)
+	}
+
+	// The reader is not read past the limit
+	r := bytes.NewReader(make([]byte, MessageMaxAttachmentByte+1024))
+	if err := message.WithAttachment(r, "large.bin", ""); err != ErrMessageAttachmentTooLarge {
+		t.Fatalf("expected %v, got %v", ErrMessageAttachmentTooLarge, err)
+	}
+
+	if r.Len() == 0 {
+		t.Error("expected the reader not to be fully read")
+	}
+}
+
+// TestAddAttachmentReader tests the attachment file name and type are sent
+func TestAddAttachmentReader(t *testing.T) {
+	message := NewMessage("Hello")
+	if err := message.AddAttachmentReader("chart.png", "image/png", strings.NewReader("data")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	req, err := message.multipartRequest("pToken", "rToken", "url", 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := req.ParseMultipartForm(1024); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	file := req.MultipartForm.File["attachment"][0]
+	if file.Filename != "chart.png" {
+		t.Errorf("expected file name %q, got %q", "chart.png", file.Filename)
+	}
+
+	if got := file.Header.Get("Content-Type"); got != "image/png" {
+		t.Errorf("expected content type %q, got %q", "image/png", got)
+	}
+}
+
+// TestAddAttachmentReset tests the file name and the type of a previous
+// attachment are not kept by a new one
+func TestAddAttachmentReset(t *testing.T) {
+	tt := []struct {
+		name string
+		add  func(*Message) error
+	}{
+		{"reader", func(m *Message) error {
+			return m.AddAttachment(strings.NewReader("data"))
+		}},
+		{"image", func(m *Message) error {
+			return m.AddAttachmentImage(image.NewRGBA(image.Rect(0, 0, 1, 1)), "png")
+		}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			message := NewMessage("Hello")
+			if err := message.WithAttachment(strings.NewReader("a,b"), "report.csv", "text/csv"); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if err := tc.add(message); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if message.attachmentName != "" || message.AttachmentType != "" {
+				t.Errorf("expected the name and the type to be reset, got %q and %q", message.attachmentName, message.AttachmentType)
+			}
+		})
+	}
+}
+
+// TestBase64Attachment tests the base64 encoded attachments and their size
+// limit
+func TestBase64Attachment(t *testing.T) {
+	message := NewMessage("Hello")
+	message.AttachmentBase64 = true
+	if err := message.AddAttachment(strings.NewReader("data")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	req, err := message.request("http://example.com", "pToken", "rToken", 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := req.ParseForm(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if got := req.PostForm.Get("attachment_base64"); got != "ZGF0YQ==" {
+		t.Errorf("expected the encoded attachment, got %q", got)
+	}
+
+	if got := req.PostForm.Get("attachment_type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("expected the detected attachment type, got %q", got)
+	}
+
+	// Under the multipart limit but over the base64 one
+	large := make([]byte, MessageMaxAttachmentBase64Byte+1)
+	if err := message.AddAttachment(bytes.NewReader(large)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := message.request("http://example.com", "pToken", "rToken", 0); err != ErrMessageAttachmentTooLarge {
+		t.Fatalf("expected %v, got %v", ErrMessageAttachmentTooLarge, err)
+	}
+
+	if encoded := base64.StdEncoding.EncodedLen(MessageMaxAttachmentBase64Byte); encoded > MessageMaxAttachmentByte {
+		t.Errorf("expected the encoded size %d to be under the limit", encoded)
+	}
+}
+
+// TestMessageValidateAll tests that all the validation errors are returned
+func TestMessageValidateAll(t *testing.T) {
+	message := &Message{
+		Title:      getRandomString(MessageTitleMaxLength + 1),
+		URLTitle:   "URL Title",
+		Priority:   6,
+		DeviceName: "device1,device^2",
+	}
+
+	expected := []error{
+		ErrMessageEmpty,
+		&LengthError{
+			Field: "title",
+			Got:   MessageTitleMaxLength + 1,
+			Max:   MessageTitleMaxLength,
+			Err:   ErrMessageTitleTooLong,
+		},
+		ErrEmptyURL,
+		ErrInvalidPriority,
+		ErrInvalidDeviceName,
+	}
+
+	if got := message.ValidateAll(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	if got := NewMessage("Hello").ValidateAll(); len(got) != 0 {
+		t.Errorf("expected no error, got %v", got)
+	}
+}
+
+// TestAttachmentCache tests the attachment is read once and sent with every
+// request
+func TestAttachmentCache(t *testing.T) {
+	message := NewMessage("Hello")
+	message.AddAttachment(bytes.NewBufferString("first attachment"))
+
+	attachmentContent := func(m *Message) string {
+		req, err := m.multipartRequest("pToken", "rToken", "url", 0)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if err := req.ParseMultipartForm(1024); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		f, _, err := req.FormFile("attachment")
+		if err != nil {
+			t.Fatalf("expected an attachment, got %v", err)
+		}
+		defer f.Close()
+
+		buf := &bytes.Buffer{}
+		buf.ReadFrom(f)
+		return buf.String()
+	}
+
+	// Copies of the message share the attachment
+	msgCopy := *message
+	for _, m := range []*Message{message, message, &msgCopy} {
+		if got := attachmentContent(m); got != "first attachment" {
+			t.Errorf("expected %q, got %q", "first attachment", got)
+		}
+	}
+
+	message.AddAttachment(bytes.NewBufferString("second attachment"))
+	if got := attachmentContent(message); got != "second attachment" {
+		t.Errorf("expected %q, got %q", "second attachment", got)
+	}
+}
+
+// TestMessageWithoutLengthLimits tests the length validations can be skipped
+func TestMessageWithoutLengthLimits(t *testing.T) {
+	message := NewMessageWithTitle(
+		getRandomString(MessageMaxLength+1),
+		getRandomString(MessageTitleMaxLength+1),
+	)
+
+	if err := message.validate(); !errors.Is(err, ErrMessageTooLong) {
+		t.Fatalf("expected %v, got %v", ErrMessageTooLong, err)
+	}
+
+	message.Apply(WithoutLengthLimits())
+	if err := message.validate(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// The other validations are kept
+	message.Priority = 6
+	if err := message.validate(); err != ErrInvalidPriority {
+		t.Fatalf("expected %v, got %v", ErrInvalidPriority, err)
+	}
+}
+
+// TestMessageBuildRequestEndpoint tests the global endpoint is not used by
+// the request builder
+func TestMessageBuildRequestEndpoint(t *testing.T) {
+	defer func(endpoint string) { APIEndpoint = endpoint }(APIEndpoint)
+	APIEndpoint = "http://localhost/1"
+
+	req, err := NewMessage("Test message").BuildRequest(context.Background(), "pToken", "rToken", "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if expected := DefaultAPIEndpoint + "/messages.json"; req.URL.String() != expected {
+		t.Errorf("expected URL %q, got %q", expected, req.URL.String())
+	}
+}
+
+// TestMessageBuildRequest tests the public request builder
+func TestMessageBuildRequest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	message := NewMessage("Test message")
+	req, err := message.BuildRequest(ctx, "pToken", "rToken", "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if expected := DefaultAPIEndpoint + "/messages.json"; req.URL.String() != expected {
+		t.Errorf("expected URL %q, got %q", expected, req.URL.String())
+	}
+
+	if req.Context() != ctx {
+		t.Error("expected the request to use the given context")
+	}
+
+	if err := req.ParseForm(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for k, v := range map[string]string{
+		"token":   "pToken",
+		"user":    "rToken",
+		"message": "Test message",
+	} {
+		if got := req.PostForm.Get(k); got != v {
+			t.Errorf("expected %s %q, got %q", k, v, got)
+		}
+	}
+
+	// With an attachment
+	if err := message.AddAttachment(strings.NewReader("data")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	req, err = message.BuildRequest(ctx, "pToken", "rToken", "http://example.com")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data") {
+		t.Errorf("expected a multipart request, got %q", req.Header.Get("Content-Type"))
+	}
+
+	// Invalid message
+	if _, err := NewMessage("").BuildRequest(ctx, "pToken", "rToken", ""); err != ErrMessageEmpty {
+		t.Errorf("expected %v, got %v", ErrMessageEmpty, err)
+	}
+}
+
+// TestMessageMultibyteLength tests the lengths are counted in characters
+// rather than bytes
+func TestMessageMultibyteLength(t *testing.T) {
+	tt := []struct {
+		name        string
+		message     *Message
+		expectedErr error
+	}{
+		{"message", &Message{Message: strings.Repeat("é", MessageMaxLength)}, nil},
+		{"message too long", &Message{Message: strings.Repeat("é", MessageMaxLength+1)}, ErrMessageTooLong},
+		{"title", &Message{Message: "Hello", Title: strings.Repeat("😊", MessageTitleMaxLength)}, nil},
+		{"URL", &Message{Message: "Hello", URL: "https://example.com/" + strings.Repeat("ü", MessageURLMaxLength-20)}, nil},
+		{"URL title", &Message{Message: "Hello", URL: "https://example.com", URLTitle: strings.Repeat("ß", MessageURLTitleMaxLength)}, nil},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.message.validate(); !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+// TestMessageFormats tests the HTML and monospace formats are exclusive
+func TestMessageFormats(t *testing.T) {
+	tt := []struct {
+		name        string
+		html        bool
+		monospace   bool
+		expected    map[string]string
+		expectedErr error
+	}{
+		{"plain", false, false, map[string]string{}, nil},
+		{"html", true, false, map[string]string{"html": "1"}, nil},
+		{"monospace", false, true, map[string]string{"monospace": "1"}, nil},
+		{"html and monospace", true, true, nil, ErrMonospaceHTMLExclusive},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			message := NewMessage("Hello")
+			message.HTML = tc.html
+			message.Monospace = tc.monospace
+
+			if err := message.validate(); err != tc.expectedErr {
+				t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if tc.expectedErr != nil {
+				return
+			}
+
+			params := message.toMap("pToken", "rToken")
+			for _, key := range []string{"html", "monospace"} {
+				if params[key] != tc.expected[key] {
+					t.Errorf("expected %s %q, got %q", key, tc.expected[key], params[key])
+				}
+			}
+		})
+	}
+}
+
+// TestMessageSetTimestamp tests the timestamp is set in seconds
+func TestMessageSetTimestamp(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.FixedZone("CET", 3600))
+
+	message := NewMessage("Hello")
+	message.SetTimestamp(ts)
+
+	if message.Timestamp != ts.Unix() {
+		t.Errorf("expected timestamp %d, got %d", ts.Unix(), message.Timestamp)
+	}
+
+	if got := message.toMap("pToken", "rToken")["timestamp"]; got != "1577930645" {
+		t.Errorf("expected timestamp %q, got %q", "1577930645", got)
+	}
+}
+
+// TestMessageHash tests the hash of the messages
+func TestMessageHash(t *testing.T) {
+	message := &Message{Message: "Hello", Title: "Title", Priority: PriorityHigh}
+
+	// The hash must never change
+	expected := "1aabce342a44713c7ece4d1ba7669cb2127757d727c32d6bc9796a40a25155a7"
+	if got := message.Hash(); got != expected {
+		t.Fatalf("expected hash %q, got %q", expected, got)
+	}
+
+	same := *message
+	same.DeviceName = "phone"
+	same.Timestamp = 1234
+	if got := same.Hash(); got != expected {
+		t.Errorf("expected the recipient fields to be ignored, got %q", got)
+	}
+
+	for name, m := range map[string]*Message{
+		"message":  {Message: "Hello!", Title: "Title", Priority: PriorityHigh},
+		"priority": {Message: "Hello", Title: "Title", Priority: PriorityNormal},
+		"shifted":  {Message: "Hello\"", Title: "\"Title", Priority: PriorityHigh},
+	} {
+		if m.Hash() == expected {
+			t.Errorf("expected a different hash for %s", name)
+		}
+	}
+}
+
+// TestMessageString tests the summary of the messages
+func TestMessageString(t *testing.T) {
+	message := NewMessageWithTitle(strings.Repeat("é", 60), "Alert")
+	message.Priority = PriorityHigh
+	message.Sound = SoundSiren
+	if err := message.AddAttachment(strings.NewReader("data")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := `title="Alert" message="` + strings.Repeat("é", 50) + `…" priority=high device=all sound="siren" attachment=true`
+	if got := message.String(); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+
+	message = &Message{Message: "Hello", Priority: 6, DeviceName: "phone"}
+	expected = `title="" message="Hello" priority=6 device=phone sound="" attachment=false`
+	if got := fmt.Sprint(message); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
+
+// TestAttachmentSizeLimit tests the size of the in-memory and streamed
+// attachments is checked
+func TestAttachmentSizeLimit(t *testing.T) {
+	// In-memory attachments are checked by the validation
+	message := NewMessage("Hello")
+	if err := message.AddAttachment(bytes.NewReader(make([]byte, MessageMaxAttachmentByte+1))); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := message.validate(); err != ErrMessageAttachmentTooLarge {
+		t.Fatalf("expected %v, got %v", ErrMessageAttachmentTooLarge, err)
+	}
+
+	// Streamed attachments are checked while they are read
+	r := bytes.NewReader(make([]byte, 2*MessageMaxAttachmentByte))
+	message = NewMessage("Hello")
+	if err := message.AddAttachment(io.MultiReader(r)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := message.validate(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := message.multipartRequest("pToken", "rToken", "url", 0); err != ErrMessageAttachmentTooLarge {
+		t.Fatalf("expected %v, got %v", ErrMessageAttachmentTooLarge, err)
+	}
+
+	if r.Len() == 0 {
+		t.Error("expected the attachment not to be fully read")
+	}
+}
+
+// TestMessageEmergencyBounds tests the bounds of the emergency parameters
+func TestMessageEmergencyBounds(t *testing.T) {
+	tt := []struct {
+		name        string
+		retry       time.Duration
+		expire      time.Duration
+		expectedErr error
+	}{
+		{"min retry", 30 * time.Second, time.Hour, nil},
+		{"retry too short", 29 * time.Second, time.Hour, ErrEmergencyRetryTooShort},
+		{"max expire", time.Minute, 10800 * time.Second, nil},
+		{"expire too long", time.Minute, 10801 * time.Second, ErrEmergencyExpireTooLong},
+		{"missing retry", 0, time.Hour, ErrMissingEmergencyParameter},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			message := &Message{
+				Message:  "Hello",
+				Priority: PriorityEmergency,
+				Retry:    tc.retry,
+				Expire:   tc.expire,
+			}
+
+			if err := message.validate(); err != tc.expectedErr {
+				t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+// TestNewEmergencyMessage tests the emergency messages are valid
+func TestNewEmergencyMessage(t *testing.T) {
+	message := NewEmergencyMessage("Hello", time.Minute, time.Hour)
+	expected := &Message{
+		Message:  "Hello",
+		Priority: PriorityEmergency,
+		Retry:    time.Minute,
+		Expire:   time.Hour,
+	}
+
+	if !reflect.DeepEqual(message, expected) {
+		t.Errorf("expected %v, got %v", expected, message)
+	}
+
+	if err := message.validate(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+// TestMessageSound tests the validation of the sounds
+func TestMessageSound(t *testing.T) {
+	tt := []struct {
+		name        string
+		sound       string
+		custom      bool
+		expectedErr error
+	}{
+		{"default", "", false, nil},
+		{"built-in", SoundCosmic, false, nil},
+		{"typo", "cosimc", false, ErrInvalidSound},
+		{"custom", "my_sound", true, nil},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			message := &Message{Message: "Hello", Sound: tc.sound, AllowCustomSound: tc.custom}
+			if err := message.validate(); err != tc.expectedErr {
+				t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+// TestLengthError tests the length errors give the number of characters
+func TestLengthError(t *testing.T) {
+	message := NewMessage(strings.Repeat("a", 1100))
+
+	err := message.validate()
+	if !errors.Is(err, ErrMessageTooLong) {
+		t.Fatalf("expected %v, got %v", ErrMessageTooLong, err)
+	}
+
+	var lengthErr *LengthError
+	if !errors.As(err, &lengthErr) {
+		t.Fatalf("expected a length error, got %T", err)
+	}
+
+	if lengthErr.Field != "message" || lengthErr.Got != 1100 || lengthErr.Max != MessageMaxLength {
+		t.Errorf("unexpected length error %+v", lengthErr)
+	}
+
+	expected := "pushover: message too long (1100 > 1024)"
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
+
+// TestMessageTTL tests the encoding and the validation of the TTL
+func TestMessageTTL(t *testing.T) {
+	tt := []struct {
+		name     string
+		ttl      time.Duration
+		expected string
+	}{
+		{"no TTL", 0, ""},
+		{"seconds", 90 * time.Second, "90"},
+		{"rounded up", 1500 * time.Millisecond, "2"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			message := &Message{Message: "Hello", TTL: tc.ttl}
+			if err := message.validate(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if got := message.toMap("pToken", "rToken")["ttl"]; got != tc.expected {
+				t.Errorf("expected ttl %q, got %q", tc.expected, got)
+			}
+		})
+	}
+
+	message := NewEmergencyMessage("Hello", time.Minute, time.Hour)
+	message.TTL = time.Hour
+	if err := message.validate(); err != ErrTTLWithEmergency {
+		t.Fatalf("expected %v, got %v", ErrTTLWithEmergency, err)
+	}
+}
+
+// TestMessageTags tests the encoding of the tags
+func TestMessageTags(t *testing.T) {
+	tt := []struct {
+		name     string
+		tags     []string
+		expected string
+	}{
+		{"no tags", nil, ""},
+		{"single tag", []string{"incident-42"}, "incident-42"},
+		{"multiple tags", []string{"incident-42", "db"}, "incident-42,db"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			message := &Message{Message: "Hello", Tags: tc.tags}
+			got, ok := message.toMap("pToken", "rToken")["tags"]
+			if ok != (tc.expected != "") || got != tc.expected {
+				t.Errorf("expected tags %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestMessageParams tests the parameters match the fields of the message
+func TestMessageParams(t *testing.T) {
+	message := &Message{
+		Message:   "Hello",
+		Title:     "Title",
+		Priority:  PriorityHigh,
+		URL:       "http://google.com",
+		URLTitle:  "Google",
+		Sound:     SoundCosmic,
+		Monospace: true,
+		Devices:   []string{"phone", "tablet"},
+	}
+
+	expected := map[string]string{
+		"message":   "Hello",
+		"title":     "Title",
+		"priority":  "1",
+		"url":       "http://google.com",
+		"url_title": "Google",
+		"sound":     SoundCosmic,
+		"monospace": "1",
+		"device":    "phone,tablet",
+	}
+
+	if got := message.Params(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+// TestMessageJSON tests a message stored as JSON is sent the same way once
+// reloaded
+func TestMessageJSON(t *testing.T) {
+	message := NewEmergencyMessage("Hello", time.Minute, 2*time.Hour)
+	message.Title = "Title"
+	message.URL = "http://google.com"
+	message.URLTitle = "Google"
+	message.CallbackURL = "http://yourapp.com/callback"
+	message.Devices = []string{"phone", "tablet"}
+	message.Sound = SoundCosmic
+	message.HTML = true
+	message.Tags = []string{"incident-42"}
+	message.Timestamp = 1424305421
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !strings.Contains(string(data), `"retry":60,"expire":7200`) {
+		t.Errorf("expected the durations in seconds, got %s", data)
+	}
+
+	var got Message
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := message.toMap("pToken", "rToken")
+	if params := got.toMap("pToken", "rToken"); !reflect.DeepEqual(params, expected) {
+		t.Errorf("expected %v, got %v", expected, params)
+	}
+}
+
+// TestMessageJSONPriority tests the messages stored with a numeric priority
+// can still be decoded
+func TestMessageJSONPriority(t *testing.T) {
+	for data, expected := range map[string]Priority{
+		`{"message":"Hello","priority":1}`:         PriorityHigh,
+		`{"message":"Hello","priority":"high"}`:    PriorityHigh,
+		`{"message":"Hello","priority":-1}`:        PriorityLow,
+		`{"message":"Hello","priority":"lowest"}`:  PriorityLowest,
+		`{"message":"Hello","priority":0,"ttl":1}`: PriorityNormal,
+	} {
+		var got Message
+		if err := json.Unmarshal([]byte(data), &got); err != nil {
+			t.Fatalf("expected no error for %s, got %v", data, err)
+		}
+
+		if got.Priority != expected {
+			t.Errorf("expected priority %d from %s, got %d", expected, data, got.Priority)
+		}
+	}
+}
+
+// TestMessageJSONDurations tests the fractional durations are kept once
+// reloaded from JSON
+func TestMessageJSONDurations(t *testing.T) {
+	tt := []struct {
+		name     string
+		message  *Message
+		expected string
+	}{
+		{"emergency", NewEmergencyMessage("Hello", 90500*time.Millisecond, 2*time.Hour), `"retry":90.5,"expire":7200`},
+		{"ttl", NewMessageWith("Hello", func(m *Message) { m.TTL = 90 * time.Second }), `"ttl":90`},
+		{"invalid priority", NewMessageWith("Hello", WithPriority(Priority(6))), `"priority":6`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := json.Marshal(tc.message)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !strings.Contains(string(data), tc.expected) {
+				t.Errorf("expected %s in %s", tc.expected, data)
+			}
+
+			var got Message
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if got.Priority != tc.message.Priority {
+				t.Errorf("expected priority %d, got %d", tc.message.Priority, got.Priority)
+			}
+
+			if got.Retry != tc.message.Retry || got.Expire != tc.message.Expire || got.TTL != tc.message.TTL {
+				t.Errorf("expected durations %v/%v/%v, got %v/%v/%v",
+					tc.message.Retry, tc.message.Expire, tc.message.TTL,
+					got.Retry, got.Expire, got.TTL)
+			}
+		})
+	}
+}
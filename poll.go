@@ -0,0 +1,48 @@
+package pushover
+
+import (
+	"context"
+	"time"
+)
+
+// PollReceipt polls the details of an emergency notification receipt every
+// interval until it's acknowledged or expired and returns the final details.
+// The polling stops when the context is done, the last details are returned
+// with the context error. The errors, e.g. for an unknown or expired receipt,
+// stop the polling right away.
+func (p *Pushover) PollReceipt(ctx context.Context, receipt string, interval time.Duration) (*ReceiptDetails, error) {
+	return p.PollReceiptWithAttempts(ctx, receipt, interval, 0)
+}
+
+// PollReceiptWithAttempts polls the details of an emergency notification
+// receipt every interval until it's acknowledged or expired and returns the
+// final details. At most maxAttempts requests are made, a non positive value
+// means no limit. When the attempts are exhausted the last details are
+// returned along with ErrPollExhausted. The polling stops when the context is
+// done, the last details are returned with the context error.
+func (p *Pushover) PollReceiptWithAttempts(ctx context.Context, receipt string, interval time.Duration, maxAttempts int) (*ReceiptDetails, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		details, err := p.GetReceiptDetails(receipt)
+		if err != nil {
+			return nil, err
+		}
+
+		if details.Acknowledged || details.Expired {
+			return details, nil
+		}
+
+		if maxAttempts > 0 && attempt >= maxAttempts {
+			return details, ErrPollExhausted
+		}
+
+		select {
+		case <-ctx.Done():
+			return details, ctx.Err()
+		case <-p.getClock().After(interval):
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package pushover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newPollServer returns a server acknowledging the receipt after the given
+// number of requests
+func newPollServer(acknowledgeAfter int) (*httptest.Server, func() int) {
+	var mu sync.Mutex
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		acknowledged := requests >= acknowledgeAfter
+		mu.Unlock()
+
+		if acknowledged {
+			fmt.Fprintln(w, `{"status":1,"acknowledged":1,"acknowledged_at":1424305421,"request":"e95f35c2d75a100a3719b3764f0c8e47"}`)
+			return
+		}
+		fmt.Fprintln(w, `{"status":1,"acknowledged":0,"request":"e95f35c2d75a100a3719b3764f0c8e47"}`)
+	}))
+
+	return ts, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+}
+
+// TestPollReceiptWithAttempts tests the receipt polling with a limited number
+// of attempts
+func TestPollReceiptWithAttempts(t *testing.T) {
+	tt := []struct {
+		name             string
+		acknowledgeAfter int
+		maxAttempts      int
+		expectedErr      error
+		expectedRequests int
+	}{
+		{"acknowledged", 3, 5, nil, 3},
+		{"exhausted", 3, 2, ErrPollExhausted, 2},
+		{"no limit", 4, 0, nil, 4},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ts, requests := newPollServer(tc.acknowledgeAfter)
+			defer ts.Close()
+
+			app := New(fakePushover.token, WithEndpoint(ts.URL))
+			details, err := app.PollReceiptWithAttempts(context.Background(), "receipt", time.Millisecond, tc.maxAttempts)
+			if err != tc.expectedErr {
+				t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if details == nil {
+				t.Fatalf("expected the last details")
+			}
+
+			if details.Acknowledged != (tc.expectedErr == nil) {
+				t.Errorf("unexpected acknowledged status")
+			}
+
+			if got := requests(); got != tc.expectedRequests {
+				t.Errorf("expected %d requests, got %d", tc.expectedRequests, got)
+			}
+		})
+	}
+}
+
+// TestPollReceipt tests the polling stops once acknowledged or when the
+// context is done
+func TestPollReceipt(t *testing.T) {
+	ts, requests := newPollServer(3)
+	defer ts.Close()
+
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+	app.SetClock(&fakeClock{now: time.Now()})
+	details, err := app.PollReceipt(context.Background(), "receipt", time.Minute)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !details.Acknowledged {
+		t.Errorf("expected the receipt to be acknowledged")
+	}
+
+	if got := requests(); got != 3 {
+		t.Errorf("expected 3 requests, got %d", got)
+	}
+
+	ts, _ = newPollServer(100)
+	defer ts.Close()
+
+	app = New(fakePushover.token, WithEndpoint(ts.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	details, err = app.PollReceipt(ctx, "receipt", time.Millisecond)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	if details == nil || details.Acknowledged {
+		t.Errorf("expected the last details not acknowledged")
+	}
+}
+
+// TestPollReceiptInvalid tests the polling stops right away for an unknown
+// or expired receipt
+func TestPollReceiptInvalid(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintln(w, `{"receipt":"not found","errors":["receipt not found; may be invalid or expired"],"status":0,"request":"e95f35c2d75a100a3719b3764f0c8e47"}`)
+	}))
+	defer ts.Close()
+
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+	app.SetClock(&fakeClock{now: time.Now()})
+
+	details, err := app.PollReceipt(context.Background(), "receipt", time.Minute)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected an APIError with a 404 status, got %v", err)
+	}
+
+	if details != nil {
+		t.Errorf("expected no details, got %+v", details)
+	}
+
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+}
+
+// TestPollReceiptWithAttemptsInvalid tests the polling without a limit of
+// attempts stops right away for an unknown or expired receipt
+func TestPollReceiptWithAttemptsInvalid(t *testing.T) {
+	for _, maxAttempts := range []int{0, -1} {
+		requests := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, `{"receipt":"not found","errors":["receipt not found; may be invalid or expired"],"status":0,"request":"e95f35c2d75a100a3719b3764f0c8e47"}`)
+		}))
+
+		app := New(fakePushover.token, WithEndpoint(ts.URL))
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		_, err := app.PollReceiptWithAttempts(ctx, "receipt", time.Millisecond, maxAttempts)
+		cancel()
+		ts.Close()
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Errorf("expected an APIError with %d max attempts, got %v", maxAttempts, err)
+		}
+
+		if requests != 1 {
+			t.Errorf("expected 1 request with %d max attempts, got %d", maxAttempts, requests)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package pushover
+
+import (
+	"html"
+	"regexp"
+)
+
+var htmlTagRegexp *regexp.Regexp
+
+func init() {
+	htmlTagRegexp = regexp.MustCompile(`<[^>]*>`)
+}
+
+// MessagePreview represents the way a message is displayed by the pushover
+// clients.
+type MessagePreview struct {
+	Title string
+	// Body is the text of the message, the HTML tags are stripped and the
+	// entities are decoded.
+	Body string
+	// Monospace is true if the body is displayed using a monospace font.
+	Monospace bool
+	// Sound is the name of the sound played, the default one if none was
+	// set.
+	Sound string
+	// Priority is the name of the priority.
+	Priority string
+}
+
+// Preview returns the way the message would be displayed by the pushover
+// clients, nothing is sent. The formatting of the HTML messages is dropped.
+func (m *Message) Preview() MessagePreview {
+	body := m.Message
+	if m.HTML {
+		body = html.UnescapeString(htmlTagRegexp.ReplaceAllString(body, ""))
+	}
+
+	sound := m.Sound
+	if sound == "" {
+		sound = SoundPushover
+	}
+
+	return MessagePreview{
+		Title:     m.Title,
+		Body:      body,
+		Monospace: m.Monospace,
+		Sound:     sound,
+		Priority:  m.Priority.String(),
+	}
+}
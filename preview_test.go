@@ -0,0 +1,36 @@
+package pushover
+
+import "testing"
+
+// TestMessagePreview tests the preview of the messages
+func TestMessagePreview(t *testing.T) {
+	tt := []struct {
+		name     string
+		message  *Message
+		expected MessagePreview
+	}{
+		{
+			name:     "plain",
+			message:  &Message{Message: "<b>5 &lt; 6</b>", Title: "Title"},
+			expected: MessagePreview{Title: "Title", Body: "<b>5 &lt; 6</b>", Sound: SoundPushover, Priority: "normal"},
+		},
+		{
+			name:     "html",
+			message:  &Message{Message: `<b>5 &lt; 6</b>, see <a href="https://example.com">this</a>`, HTML: true, Sound: SoundSiren},
+			expected: MessagePreview{Body: "5 < 6, see this", Sound: SoundSiren, Priority: "normal"},
+		},
+		{
+			name:     "monospace",
+			message:  &Message{Message: "a  b", Monospace: true, Priority: PriorityEmergency},
+			expected: MessagePreview{Body: "a  b", Monospace: true, Sound: SoundPushover, Priority: "emergency"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.message.Preview(); got != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package pushover
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Priority represents the priority of a message.
+type Priority int
+
+// Message priorities
+const (
+	PriorityLowest    Priority = -2
+	PriorityLow       Priority = -1
+	PriorityNormal    Priority = 0
+	PriorityHigh      Priority = 1
+	PriorityEmergency Priority = 2
+)
+
+var priorityNames = map[Priority]string{
+	PriorityLowest:    "lowest",
+	PriorityLow:       "low",
+	PriorityNormal:    "normal",
+	PriorityHigh:      "high",
+	PriorityEmergency: "emergency",
+}
+
+// Priorities returns all the valid priorities from the lowest to the highest.
+func Priorities() []Priority {
+	return []Priority{
+		PriorityLowest,
+		PriorityLow,
+		PriorityNormal,
+		PriorityHigh,
+		PriorityEmergency,
+	}
+}
+
+// ParsePriority returns the priority from its name, e.g. "emergency", or its
+// numeric value.
+func ParsePriority(s string) (Priority, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for p, name := range priorityNames {
+		if name == s {
+			return p, nil
+		}
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, ErrInvalidPriority
+	}
+
+	p := Priority(i)
+	if _, ok := priorityNames[p]; !ok {
+		return 0, ErrInvalidPriority
+	}
+
+	return p, nil
+}
+
+// String returns the name of the priority, e.g. "emergency", or its numeric
+// value if it's not valid.
+func (p Priority) String() string {
+	name, ok := priorityNames[p]
+	if !ok {
+		return strconv.Itoa(int(p))
+	}
+
+	return name
+}
+
+// MarshalText implements the encoding.TextMarshaler interface, the priority
+// is encoded using its name or its numeric value if it's not valid.
+func (p Priority) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, the priority is
+// encoded as a string using its name or as a number if it's not valid so it
+// can be decoded back.
+func (p Priority) MarshalJSON() ([]byte, error) {
+	name, ok := priorityNames[p]
+	if !ok {
+		return json.Marshal(int(p))
+	}
+
+	return json.Marshal(name)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, the priority can
+// either be a number like in the API or a string accepted by ParsePriority.
+// The numbers are not checked, the messages are validated before being sent.
+func (p *Priority) UnmarshalJSON(data []byte) error {
+	var i int
+	if err := json.Unmarshal(data, &i); err == nil {
+		*p = Priority(i)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return ErrInvalidPriority
+	}
+
+	return p.UnmarshalText([]byte(s))
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface, see
+// ParsePriority for the accepted values.
+func (p *Priority) UnmarshalText(text []byte) error {
+	priority, err := ParsePriority(string(text))
+	if err != nil {
+		return err
+	}
+
+	*p = priority
+	return nil
+}
@@ -0,0 +1,99 @@
+package pushover
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestParsePriority tests the parsing of the priorities
+func TestParsePriority(t *testing.T) {
+	tt := []struct {
+		input    string
+		expected Priority
+		err      error
+	}{
+		{"lowest", PriorityLowest, nil},
+		{"Low", PriorityLow, nil},
+		{"normal", PriorityNormal, nil},
+		{" high ", PriorityHigh, nil},
+		{"emergency", PriorityEmergency, nil},
+		{"2", PriorityEmergency, nil},
+		{"-2", PriorityLowest, nil},
+		{"3", 0, ErrInvalidPriority},
+		{"urgent", 0, ErrInvalidPriority},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := ParsePriority(tc.input)
+			if err != tc.err {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+
+			if got != tc.expected {
+				t.Errorf("expected %d, got %d", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestPriorityJSON tests the priorities round trip through JSON
+func TestPriorityJSON(t *testing.T) {
+	for _, p := range Priorities() {
+		data, err := json.Marshal(p)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		var got Priority
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if got != p {
+			t.Errorf("expected %d, got %d from %s", p, got, data)
+		}
+	}
+
+	// The invalid priorities are kept as numbers
+	data, err := json.Marshal(Priority(6))
+	if err != nil || string(data) != "6" {
+		t.Errorf("expected 6, got %s, %v", data, err)
+	}
+
+	// The numbers of the API and the names are accepted
+	for data, expected := range map[string]Priority{`1`: PriorityHigh, `-2`: PriorityLowest, `"high"`: PriorityHigh, `"2"`: PriorityEmergency, `6`: Priority(6)} {
+		var got Priority
+		if err := json.Unmarshal([]byte(data), &got); err != nil || got != expected {
+			t.Errorf("expected %d from %s, got %d, %v", expected, data, got, err)
+		}
+	}
+
+	for _, data := range []string{`"urgent"`, `1.5`, `true`} {
+		var got Priority
+		if err := json.Unmarshal([]byte(data), &got); err == nil {
+			t.Errorf("expected an error for %s", data)
+		}
+	}
+}
+
+// TestPriorityString tests the names of the priorities
+func TestPriorityString(t *testing.T) {
+	tt := []struct {
+		priority Priority
+		expected string
+	}{
+		{PriorityLowest, "lowest"},
+		{PriorityLow, "low"},
+		{PriorityNormal, "normal"},
+		{PriorityHigh, "high"},
+		{PriorityEmergency, "emergency"},
+		{Priority(6), "6"},
+	}
+
+	for _, tc := range tt {
+		if got := tc.priority.String(); got != tc.expected {
+			t.Errorf("expected %q, got %q", tc.expected, got)
+		}
+	}
+}
@@ -2,22 +2,39 @@
 package pushover
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"net/url"
 	"regexp"
+	"strings"
+	"sync"
+	"time"
 )
 
 // Regexp validation.
-var tokenRegexp *regexp.Regexp
+var tokenRegexp, requestIDRegexp *regexp.Regexp
 
 func init() {
 	tokenRegexp = regexp.MustCompile(`^[A-Za-z0-9]{30}$`)
+	requestIDRegexp = regexp.MustCompile(`^[0-9a-f]{32}$`)
 }
 
-// APIEndpoint is the API base URL for any request.
-var APIEndpoint = "https://api.pushover.net/1"
+// Version is the version of the library, it's updated at release time.
+const Version = "1.4.0"
+
+// DefaultAPIEndpoint is the base URL of the pushover API.
+const DefaultAPIEndpoint = "https://api.pushover.net/1"
+
+// APIEndpoint is the API base URL used by the apps created with New, it can
+// be overridden per app with WithEndpoint.
+var APIEndpoint = DefaultAPIEndpoint
+
+// DefaultUserAgent is the User-Agent header of the requests sent to the API,
+// it includes the version of the library. See WithUserAgent.
+const DefaultUserAgent = "go-pushover/" + Version
 
 // Pushover custom errors.
 var (
@@ -26,6 +43,8 @@ var (
 	ErrEmptyURL                  = errors.New("pushover: empty URL, URLTitle needs an URL")
 	ErrEmptyRecipientToken       = errors.New("pushover: empty recipient token")
 	ErrInvalidRecipientToken     = errors.New("pushover: invalid recipient token")
+	ErrInvalidRecipient          = errors.New("pushover: invalid recipient")
+	ErrBatchAborted              = errors.New("pushover: batch aborted")
 	ErrInvalidHeaders            = errors.New("pushover: invalid headers in server response")
 	ErrInvalidPriority           = errors.New("pushover: invalid priority")
 	ErrInvalidToken              = errors.New("pushover: invalid API token")
@@ -36,9 +55,33 @@ var (
 	ErrMessageURLTitleTooLong    = errors.New("pushover: message URL title too long")
 	ErrMessageURLTooLong         = errors.New("pushover: message URL too long")
 	ErrMissingAttachment         = errors.New("pushover: missing attachment")
+	ErrInvalidImageFormat        = errors.New("pushover: invalid image format")
+	ErrInvalidCallbackURL        = errors.New("pushover: invalid callback URL")
+	ErrInvalidCallbackSignature  = errors.New("pushover: invalid callback signature")
+	ErrInvalidCallbackID         = errors.New("pushover: empty callback id")
+	ErrInvalidMessageFormat      = errors.New("pushover: invalid message format")
 	ErrMissingEmergencyParameter = errors.New("pushover: missing emergency parameter")
 	ErrInvalidDeviceName         = errors.New("pushover: invalid device name")
+	ErrDeviceNameSeparator       = errors.New("pushover: device name contains the device separator")
 	ErrEmptyReceipt              = errors.New("pushover: empty receipt")
+	ErrReceiptNotFound           = errors.New("pushover: receipt not found")
+	ErrQuotaExhausted            = errors.New("pushover: message quota exhausted")
+	ErrMalformedResponse         = errors.New("pushover: malformed response")
+	ErrRateLimited               = errors.New("pushover: app rate limit exceeded")
+	ErrPollExhausted             = errors.New("pushover: receipt poll attempts exhausted")
+	ErrEmptyResponse             = errors.New("pushover: empty response body")
+	ErrRequestTooLarge           = errors.New("pushover: request too large")
+	ErrEmptyGroupName            = errors.New("pushover: empty group name")
+	ErrInvalidGroupKey           = errors.New("pushover: invalid group key")
+	ErrEmptySecret               = errors.New("pushover: empty user secret")
+	ErrTwoFactorRequired         = errors.New("pushover: two-factor authentication code required")
+	ErrMonospaceHTMLExclusive    = errors.New("pushover: html and monospace can't be used together")
+	ErrEmergencyRetryTooShort    = errors.New("pushover: emergency retry too short")
+	ErrEmergencyExpireTooLong    = errors.New("pushover: emergency expire too long")
+	ErrEmptyTag                  = errors.New("pushover: empty tag")
+	ErrInvalidRecipientsFormat   = errors.New("pushover: invalid recipients format")
+	ErrInvalidSound              = errors.New("pushover: invalid sound")
+	ErrTTLWithEmergency          = errors.New("pushover: ttl can't be used with the emergency priority")
 	ErrGlancesMissingData        = errors.New("pushover: glance update data missing")
 	ErrGlancesTitleTooLong       = errors.New("pushover: glance title too long")
 	ErrGlancesTextTooLong        = errors.New("pushover: glance text too long")
@@ -58,16 +101,19 @@ const (
 	MessageURLTitleMaxLength = 100
 	// MessageMaxAttachmentByte is the max attachment size in byte.
 	MessageMaxAttachmentByte = 2621440
+	// MessageMaxAttachmentBase64Byte is the max attachment size in byte
+	// before its base64 encoding, so the encoded attachment stays under
+	// MessageMaxAttachmentByte.
+	MessageMaxAttachmentBase64Byte = MessageMaxAttachmentByte / 4 * 3
+	// MessageMinRetry is the min retry duration of the emergency messages.
+	MessageMinRetry = 30 * time.Second
+	// MessageMaxExpire is the max expire duration of the emergency messages.
+	MessageMaxExpire = 10800 * time.Second
 )
 
-// Message priorities
-const (
-	PriorityLowest    = -2
-	PriorityLow       = -1
-	PriorityNormal    = 0
-	PriorityHigh      = 1
-	PriorityEmergency = 2
-)
+// DefaultUploadBufferSize is the default size of the buffer used to upload
+// the attachments.
+const DefaultUploadBufferSize = 32 * 1024
 
 // Sounds
 const (
@@ -96,14 +142,164 @@ const (
 	SoundNone         = "none"
 )
 
-// Pushover is the representation of an app using the pushover API.
+// builtinSounds is the set of the sounds provided by pushover.
+var builtinSounds = map[string]bool{
+	SoundPushover:     true,
+	SoundBike:         true,
+	SoundBugle:        true,
+	SoundCashRegister: true,
+	SoundClassical:    true,
+	SoundCosmic:       true,
+	SoundFalling:      true,
+	SoundGamelan:      true,
+	SoundIncoming:     true,
+	SoundIntermission: true,
+	SoundMagic:        true,
+	SoundMechanical:   true,
+	SoundPianobar:     true,
+	SoundSiren:        true,
+	SoundSpaceAlarm:   true,
+	SoundTugBoat:      true,
+	SoundAlien:        true,
+	SoundClimb:        true,
+	SoundPersistent:   true,
+	SoundEcho:         true,
+	SoundUpDown:       true,
+	SoundVibrate:      true,
+	SoundNone:         true,
+}
+
+// Pushover is the representation of an app using the pushover API. It's safe
+// for concurrent use by multiple goroutines, including its setters.
 type Pushover struct {
-	token string
+	// Accessed atomically, kept first for the 64-bit alignment
+	stats stats
+
+	token     string
+	endpoint  string
+	userAgent string
+	async     inflight
+
+	mu           sync.Mutex
+	client       *http.Client
+	receiptStore ReceiptStore
+	limit        *Limit
+	quotaReserve int
+	emoji        bool
+	glances      *glanceCoalescer
+	uploadBuffer int
+	checkIDs     bool
+	rate         *rateLimiter
+	rateNoWait   bool
+	recipient    *Recipient
+	auditSink    AuditSink
+	lowerDevices bool
+	fallback     bool
+	successes    []int
+	clock        Clock
+	maxRequest   int64
+	retries      int
+	retryDelay   time.Duration
+	interceptor  func(*http.Request) error
+	limitWait    bool
+	tokenRegexp  *regexp.Regexp
+	logger       *log.Logger
+	warnToken    sync.Once
+	asyncSlots   chan struct{}
+}
+
+// Option represents an option used to configure the app.
+type Option func(*Pushover)
+
+// WithHTTPClient sets the HTTP client used to talk to the API.
+func WithHTTPClient(client *http.Client) Option {
+	return func(p *Pushover) {
+		p.client = client
+	}
+}
+
+// WithEndpoint sets the base URL of the API used by the app, APIEndpoint is
+// used by default.
+func WithEndpoint(url string) Option {
+	return func(p *Pushover) {
+		p.endpoint = url
+	}
+}
+
+// WithUserAgent sets the User-Agent header of the requests sent to the API,
+// DefaultUserAgent is used by default.
+func WithUserAgent(userAgent string) Option {
+	return func(p *Pushover) {
+		p.userAgent = userAgent
+	}
+}
+
+// WithRateLimitWait makes the messages sent when the quota is exhausted wait
+// for the next reset, or the cancellation of the context, instead of failing
+// with ErrQuotaExhausted. The quota is known from the limits received with
+// the last notification sent.
+func WithRateLimitWait(wait bool) Option {
+	return func(p *Pushover) {
+		p.limitWait = wait
+	}
 }
 
 // New returns a new app to talk to the pushover API.
-func New(token string) *Pushover {
-	return &Pushover{token}
+func New(token string, opts ...Option) *Pushover {
+	p := &Pushover{token: token, endpoint: APIEndpoint}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Endpoint returns the base URL of the API used by the app.
+func (p *Pushover) Endpoint() string {
+	if p.endpoint == "" {
+		return DefaultAPIEndpoint
+	}
+
+	return p.endpoint
+}
+
+// UserAgent returns the User-Agent header of the requests sent to the API.
+func (p *Pushover) UserAgent() string {
+	if p.userAgent == "" {
+		return DefaultUserAgent
+	}
+
+	return p.userAgent
+}
+
+// HTTPClient returns the HTTP client used to talk to the API,
+// http.DefaultClient is used if none was set.
+func (p *Pushover) HTTPClient() *http.Client {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.client == nil {
+		return http.DefaultClient
+	}
+
+	return p.client
+}
+
+// SetReceiptStore sets the store used to keep track of the receipts of the
+// emergency notifications sent by the app. Receipts are not tracked by
+// default.
+func (p *Pushover) SetReceiptStore(store ReceiptStore) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.receiptStore = store
+}
+
+// ReceiptStore returns the store used to keep track of the receipts, it's nil
+// if receipts are not tracked.
+func (p *Pushover) ReceiptStore() ReceiptStore {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.receiptStore
 }
 
 // Validate Pushover token.
@@ -114,14 +310,390 @@ func (p *Pushover) validate() error {
 	}
 
 	// Check invalid token
-	if !tokenRegexp.MatchString(p.token) {
+	pattern, custom := p.tokenPattern()
+	if !pattern.MatchString(p.token) {
 		return ErrInvalidToken
 	}
+
+	// The app tokens generated by pushover start with an "a", warn once
+	// about the other ones in case the format changed
+	if !custom && !strings.HasPrefix(p.token, "a") {
+		p.warnToken.Do(func() {
+			p.logf("pushover: warning: unusual app token shape, tokens usually start with \"a\"")
+		})
+	}
 	return nil
 }
 
-// SendMessage is used to send message to a recipient.
-func (p *Pushover) SendMessage(message *Message, recipient *Recipient) (*Response, error) {
+// SetTokenPattern sets the regular expression used to validate the app
+// token, which allows to adapt to a change of the token format. The default
+// pattern is 30 alphanumeric characters.
+func (p *Pushover) SetTokenPattern(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tokenRegexp = re
+	return nil
+}
+
+// tokenPattern returns the regular expression used to validate the app token
+// and whether it was set with SetTokenPattern.
+func (p *Pushover) tokenPattern() (*regexp.Regexp, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.tokenRegexp == nil {
+		return tokenRegexp, false
+	}
+
+	return p.tokenRegexp, true
+}
+
+// SetLogger sets the logger used for the warnings, nothing is logged by
+// default.
+func (p *Pushover) SetLogger(logger *log.Logger) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.logger = logger
+}
+
+// logf logs a message with the logger of the app if any.
+func (p *Pushover) logf(format string, v ...interface{}) {
+	p.mu.Lock()
+	logger := p.logger
+	p.mu.Unlock()
+
+	if logger == nil {
+		return
+	}
+
+	logger.Printf(format, v...)
+}
+
+// SetDefaultRecipient sets the recipient used by Notify.
+func (p *Pushover) SetDefaultRecipient(recipient *Recipient) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.recipient = recipient
+}
+
+// DefaultRecipient returns the recipient used by Notify, it's nil if none was
+// set.
+func (p *Pushover) DefaultRecipient() *Recipient {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.recipient
+}
+
+// SetEmojiShortcodes enables the expansion of the emoji shortcodes such as
+// ":fire:" in the message and title. The length of the messages is validated
+// after the expansion.
+func (p *Pushover) SetEmojiShortcodes(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.emoji = enabled
+}
+
+// emojiShortcodes returns true if the emoji shortcodes should be expanded.
+func (p *Pushover) emojiShortcodes() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.emoji
+}
+
+// SetLowercaseDevices enables the normalization of the device names of the
+// messages and glance updates to lowercase before their validation. This
+// avoids case mismatches with the device names registered on Pushover.
+func (p *Pushover) SetLowercaseDevices(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lowerDevices = enabled
+}
+
+// lowercaseDevices returns true if the device names should be lowercased.
+func (p *Pushover) lowercaseDevices() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lowerDevices
+}
+
+// SetDeviceFallback enables sending the messages again to all the devices of
+// the recipient when the API rejects their device name, e.g. after a device
+// was renamed. The DeviceFallback field of the responses is set when it
+// happens. It's disabled by default.
+func (p *Pushover) SetDeviceFallback(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fallback = enabled
+}
+
+// deviceFallback returns true if the messages should be sent to all the
+// devices when their device is not found.
+func (p *Pushover) deviceFallback() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.fallback
+}
+
+// SetSuccessStatuses sets the status values of the API responses considered
+// as a success, the other values are errors. Only the status 1 is a success
+// by default, this allows to use gateways extending the API responses.
+func (p *Pushover) SetSuccessStatuses(statuses ...int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.successes = append([]int(nil), statuses...)
+}
+
+// successStatus returns true if the status of a response is a success.
+func (p *Pushover) successStatus(status int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.successes) == 0 {
+		return status == 1
+	}
+
+	for _, s := range p.successes {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetMaxRequestSize sets the max size in bytes of the body of the requests
+// sending a message, ErrRequestTooLarge is returned without sending the
+// request if it's exceeded. This helps to diagnose the rejections of the
+// gateways with strict body size limits. There is no limit by default.
+func (p *Pushover) SetMaxRequestSize(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.maxRequest = n
+}
+
+// checkRequestSize returns an error wrapping ErrRequestTooLarge if the body
+// of the request exceeds the max size.
+func (p *Pushover) checkRequestSize(req *http.Request) error {
+	p.mu.Lock()
+	max := p.maxRequest
+	p.mu.Unlock()
+
+	if max > 0 && req.ContentLength > max {
+		return fmt.Errorf("%w: %d bytes, max %d", ErrRequestTooLarge, req.ContentLength, max)
+	}
+
+	return nil
+}
+
+// SetRequestInterceptor sets a function called with every request right
+// before it's sent, it can modify the request to add tracing or
+// authentication headers. The request is not sent and the error is returned
+// if the function fails.
+func (p *Pushover) SetRequestInterceptor(interceptor func(*http.Request) error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.interceptor = interceptor
+}
+
+// requestInterceptor returns the request interceptor, it's nil if none was
+// set.
+func (p *Pushover) requestInterceptor() func(*http.Request) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.interceptor
+}
+
+// SetUploadBufferSize sets the size of the buffer used to read the
+// attachments, which is the size of the chunks read from their readers.
+// DefaultUploadBufferSize is used by default.
+func (p *Pushover) SetUploadBufferSize(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.uploadBuffer = n
+}
+
+// uploadBufferSize returns the size of the buffer used to upload the
+// attachments.
+func (p *Pushover) uploadBufferSize() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.uploadBuffer <= 0 {
+		return DefaultUploadBufferSize
+	}
+
+	return p.uploadBuffer
+}
+
+// SetResponseIDCheck enables the check of the request ID of the successful
+// responses, ErrMalformedResponse is returned if it's not 32 hexadecimal
+// characters. This guards against truncated or garbage responses from
+// intermediaries.
+func (p *Pushover) SetResponseIDCheck(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.checkIDs = enabled
+}
+
+// responseIDCheck returns true if the request IDs should be checked.
+func (p *Pushover) responseIDCheck() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.checkIDs
+}
+
+// SetGlobalRate limits the rate of the messages sent by the app to n messages
+// per period, regardless of the number of goroutines or recipients. The send
+// blocks until the rate allows it unless SetGlobalRateBlocking is used to
+// return ErrRateLimited instead. A non positive n removes the limit.
+func (p *Pushover) SetGlobalRate(n int, per time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if n <= 0 || per <= 0 {
+		p.rate = nil
+		return
+	}
+
+	p.rate = newRateLimiter(n, per, p.clockLocked().Now())
+}
+
+// SetGlobalRateBlocking sets whether the sends exceeding the global rate
+// should wait, which is the default, or fail with ErrRateLimited.
+func (p *Pushover) SetGlobalRateBlocking(block bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rateNoWait = !block
+}
+
+// waitGlobalRate waits until the global rate allows to send a message or the
+// context is done.
+func (p *Pushover) waitGlobalRate(ctx context.Context) error {
+	p.mu.Lock()
+	rate, wait, clock := p.rate, !p.rateNoWait, p.clockLocked()
+	p.mu.Unlock()
+
+	if rate == nil {
+		return nil
+	}
+
+	delay, err := rate.reserve(clock.Now(), wait)
+	if err != nil {
+		return err
+	}
+
+	if delay <= 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		// The reserved token is not used
+		rate.release()
+		return ctx.Err()
+	case <-clock.After(delay):
+		return nil
+	}
+}
+
+// cachedLimit returns the app limits received with the last notification
+// sent, it's nil if nothing has been sent yet.
+func (p *Pushover) cachedLimit() *Limit {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.limit
+}
+
+// cacheLimit keeps the app limits received with a response.
+func (p *Pushover) cacheLimit(response *Response) {
+	if response.Limit == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.limit = response.Limit
+}
+
+// SetQuotaReserve sets the number of messages kept for the important
+// notifications. Once the remaining quota reaches this number the best effort
+// messages are not sent anymore. The reserve is zero by default.
+func (p *Pushover) SetQuotaReserve(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.quotaReserve = n
+}
+
+// quotaReserved returns true if the remaining quota is reserved for the
+// important notifications according to the cached limits.
+func (p *Pushover) quotaReserved() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.limit == nil {
+		return false
+	}
+
+	return p.limit.Remaining <= p.quotaReserve && p.clockLocked().Now().Before(p.limit.NextReset)
+}
+
+// RemainingMessages returns the number of messages remaining and the time of
+// the next reset according to the limits received with the last notification
+// sent. The last value is false if nothing has been sent yet.
+func (p *Pushover) RemainingMessages() (int, time.Time, bool) {
+	limit := p.cachedLimit()
+	if limit == nil {
+		return 0, time.Time{}, false
+	}
+
+	return limit.Remaining, limit.NextReset, true
+}
+
+// waitQuota waits for the next reset if the quota is exhausted according to
+// the cached limits.
+func (p *Pushover) waitQuota(ctx context.Context) error {
+	limit := p.cachedLimit()
+	if limit == nil || limit.Remaining > 0 {
+		return nil
+	}
+
+	clock := p.getClock()
+	delay := limit.NextReset.Sub(clock.Now())
+	if delay <= 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-clock.After(delay):
+		return nil
+	}
+}
+
+// checkQuota returns ErrQuotaExhausted if the cached limits show that no
+// message can be sent until the next reset.
+func (p *Pushover) checkQuota() error {
+	limit := p.cachedLimit()
+	if limit == nil {
+		return nil
+	}
+
+	if limit.Remaining <= 0 && p.now().Before(limit.NextReset) {
+		return ErrQuotaExhausted
+	}
+
+	return nil
+}
+
+// prepareMessage validates the app, the recipient and the message and
+// returns the message to send to this recipient.
+func (p *Pushover) prepareMessage(message *Message, recipient *Recipient) (*Message, error) {
 	// Validate pushover
 	if err := p.validate(); err != nil {
 		return nil, err
@@ -132,17 +704,378 @@ func (p *Pushover) SendMessage(message *Message, recipient *Recipient) (*Respons
 		return nil, err
 	}
 
+	// Get the message for this recipient
+	message = message.forRecipient(recipient)
+
+	if p.lowercaseDevices() {
+		message.DeviceName = strings.ToLower(message.DeviceName)
+		if len(message.Devices) > 0 {
+			devices := make([]string, len(message.Devices))
+			for i, d := range message.Devices {
+				devices[i] = strings.ToLower(d)
+			}
+			message.Devices = devices
+		}
+	}
+
+	if p.emojiShortcodes() {
+		message.Message = expandShortcodes(message.Message)
+		message.Title = expandShortcodes(message.Title)
+	}
+
 	// Validate message
 	if err := message.validate(); err != nil {
 		return nil, err
 	}
 
-	return message.send(p.token, recipient.token)
+	return message, nil
+}
+
+// PreflightSend checks if the message could be sent to the recipient right
+// now without sending it. The message is validated and the quota is checked
+// using the limits received with the last notification sent,
+// ErrQuotaExhausted is returned if no message remains.
+func (p *Pushover) PreflightSend(ctx context.Context, message *Message, recipient *Recipient) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if _, err := p.prepareMessage(message, recipient); err != nil {
+		return err
+	}
+
+	return p.checkQuota()
+}
+
+// SendMessage is used to send message to a recipient. The best effort
+// messages are not sent when the quota is low, the Skipped field of the
+// response is set instead. ErrQuotaExhausted is returned without calling the
+// API if the limits received with the last notification show that the quota
+// is exhausted until the next reset, unless WithRateLimitWait is used.
+func (p *Pushover) SendMessage(message *Message, recipient *Recipient) (*Response, error) {
+	return p.SendMessageContext(context.Background(), message, recipient)
+}
+
+// SendMessageContext is like SendMessage, the context is used for the whole
+// send including the wait for the global rate and the upload of the
+// attachment.
+func (p *Pushover) SendMessageContext(ctx context.Context, message *Message, recipient *Recipient) (*Response, error) {
+	response, err := p.sendMessage(ctx, message, recipient)
+	p.stats.record(response, err)
+	return response, err
+}
+
+// BatchOption represents an option used to configure a batch send.
+type BatchOption func(*batchConfig)
+
+// batchConfig is the configuration of a batch send.
+type batchConfig struct {
+	failFast        bool
+	callbackURLFunc func(*Recipient) string
+	formatFunc      func(*Recipient) MessageFormat
+}
+
+// WithFailFast makes a batch send fail before sending anything if a
+// recipient is nil or has an empty token. The error of those recipients is
+// ErrInvalidRecipient and the error of the others is ErrBatchAborted.
+func WithFailFast() BatchOption {
+	return func(c *batchConfig) {
+		c.failFast = true
+	}
+}
+
+// WithCallbackURLFunc sets the callback URL of the emergency messages for
+// each recipient of a batch send, it overrides the CallbackURL of the message.
+// The recipients with an invalid URL get ErrInvalidCallbackURL.
+func WithCallbackURLFunc(fn func(*Recipient) string) BatchOption {
+	return func(c *batchConfig) {
+		c.callbackURLFunc = fn
+	}
+}
+
+// WithFormatFunc sets the format of the message for each recipient of a batch
+// send, it overrides the HTML and Monospace fields of the message. The
+// recipients with an invalid format get ErrInvalidMessageFormat.
+func WithFormatFunc(fn func(*Recipient) MessageFormat) BatchOption {
+	return func(c *batchConfig) {
+		c.formatFunc = fn
+	}
+}
+
+// message returns a copy of the message with the values of the options
+// computed for the given recipient.
+func (c *batchConfig) message(m *Message, recipient *Recipient) (*Message, error) {
+	msg := *m
+
+	if c.formatFunc != nil {
+		format := c.formatFunc(recipient)
+		if format < FormatPlain || format > FormatMonospace {
+			return nil, ErrInvalidMessageFormat
+		}
+
+		msg.HTML = format == FormatHTML
+		msg.Monospace = format == FormatMonospace
+	}
+
+	if m.Priority == PriorityEmergency && c.callbackURLFunc != nil {
+		msg.CallbackURL = c.callbackURLFunc(recipient)
+		if err := validateCallbackURL(msg.CallbackURL); err != nil {
+			return nil, err
+		}
+	}
+
+	return &msg, nil
+}
+
+// SendMessageToMultiple sends the message to each recipient, a few at a time.
+// The responses and the errors are in the order of the recipients. The
+// message is not modified, an attachment is read once and its content is
+// shared by the sends. The recipients not sent to once the context is done
+// get the context error.
+//
+// The nil recipients and the ones with an empty token are skipped by default,
+// their error is ErrInvalidRecipient and the others are sent anyway, see
+// WithFailFast to send nothing instead.
+func (p *Pushover) SendMessageToMultiple(ctx context.Context, message *Message, recipients []*Recipient, opts ...BatchOption) ([]*Response, []error) {
+	config := &batchConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	responses := make([]*Response, len(recipients))
+	errs := make([]error, len(recipients))
+	sent := make([]bool, len(recipients))
+
+	empty := false
+	for i, r := range recipients {
+		if r == nil || r.token == "" {
+			errs[i] = ErrInvalidRecipient
+			sent[i] = true
+			empty = true
+		}
+	}
+
+	if empty && config.failFast {
+		for i := range errs {
+			if errs[i] == nil {
+				errs[i] = ErrBatchAborted
+			}
+		}
+		return responses, errs
+	}
+
+	forEach(ctx, len(recipients), func(i int) {
+		if sent[i] {
+			return
+		}
+		sent[i] = true
+
+		msg, err := config.message(message, recipients[i])
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		responses[i], errs[i] = p.SendMessageContext(ctx, msg, recipients[i])
+	})
+
+	for i := range recipients {
+		if !sent[i] {
+			errs[i] = ctx.Err()
+		}
+	}
+
+	return responses, errs
+}
+
+// sendToAllDevices sends the message again to all the devices of the
+// recipient, see SetDeviceFallback.
+func (p *Pushover) sendToAllDevices(ctx context.Context, message *Message, recipient *Recipient) (*Response, error) {
+	m := *message
+	m.DeviceName = DeviceAll
+	m.Devices = nil
+
+	// The default device of the recipient is not used either
+	r := *recipient
+	r.device = ""
+
+	response, err := p.sendMessage(ctx, &m, &r)
+	if response != nil {
+		response.DeviceFallback = true
+	}
+
+	return response, err
+}
+
+// sendMessage sends a message to a recipient.
+func (p *Pushover) sendMessage(ctx context.Context, message *Message, recipient *Recipient) (*Response, error) {
+	message, err := p.prepareMessage(message, recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.BestEffort && p.quotaReserved() {
+		return &Response{Skipped: true}, nil
+	}
+
+	if p.limitWait {
+		if err := p.waitQuota(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.checkQuota(); err != nil {
+		return nil, err
+	}
+
+	if err := p.waitGlobalRate(ctx); err != nil {
+		return nil, err
+	}
+
+	// The request is built again for each attempt since its body is
+	// consumed
+	newRequest := func() (*http.Request, error) {
+		req, err := message.request(p.Endpoint(), p.token, recipient.token, p.uploadBufferSize())
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.checkRequestSize(req); err != nil {
+			return nil, err
+		}
+
+		return req, nil
+	}
+
+	response := &Response{}
+	attempts, elapsed, err := p.doRetry(withAuditDetails(ctx, message, recipient), newRequest, response, true)
+	if err != nil {
+		if response.Device == "invalid" && message.device() != DeviceAll && p.deviceFallback() {
+			return p.sendToAllDevices(ctx, message, recipient)
+		}
+		return nil, err
+	}
+	p.cacheLimit(response)
+	response.Attempts = attempts
+	response.RetryElapsed = elapsed
+	if cache := message.attachmentCache; cache != nil {
+		p.stats.addAttachmentBytes(len(cache.data))
+	}
+	response.HTMLSent = message.HTML
+	response.MonospaceSent = message.Monospace
+
+	// Keep track of the emergency receipts, the response is returned along
+	// with the error since the message has been sent anyway
+	if store := p.ReceiptStore(); store != nil && response.Receipt != "" {
+		err := store.Save(&TrackedReceipt{
+			Receipt: response.Receipt,
+			SentAt:  p.now(),
+		})
+		if err != nil {
+			return response, err
+		}
+	}
+
+	return response, nil
+}
+
+// SetGlanceCoalesce limits the glance updates to one per interval for each
+// recipient and device. The updates sent during an interval are collapsed:
+// only the latest one is sent at the end of the interval and the Coalesced
+// field of the responses is set. Errors of the delayed updates are dropped.
+// An interval of zero disables the coalescing, which is the default.
+func (p *Pushover) SetGlanceCoalesce(interval time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if interval <= 0 {
+		p.glances = nil
+		return
+	}
+
+	p.glances = newGlanceCoalescer(interval, p.getClock, p.sendGlance)
+}
+
+// glanceCoalescer returns the glance coalescer, it's nil if disabled.
+func (p *Pushover) glanceCoalescer() *glanceCoalescer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.glances
+}
+
+// Flush sends the pending coalesced glance updates right away and waits
+// until they are sent, see SetGlanceCoalesce, as well as the messages sent
+// with SendAsync. Unlike the delayed sends, the first error is returned. The
+// app can still be used afterwards.
+func (p *Pushover) Flush(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var err error
+	if c := p.glanceCoalescer(); c != nil {
+		err = c.flush(ctx)
+	}
+
+	if waitErr := p.async.wait(ctx); waitErr != nil {
+		return waitErr
+	}
+
+	return err
+}
+
+// SendAsync sends a message in the background and calls done, if not nil,
+// with the result once sent. At most a few messages are sent at the same
+// time, the others are queued. The sends go through the same limits as
+// SendMessage, use Flush to wait for them.
+func (p *Pushover) SendAsync(message *Message, recipient *Recipient, done func(*Response, error)) {
+	slots := p.asyncSendSlots()
+
+	p.async.add()
+	go func() {
+		defer p.async.done()
+
+		slots <- struct{}{}
+		response, err := p.SendMessage(message, recipient)
+		<-slots
+
+		if done != nil {
+			done(response, err)
+		}
+	}()
+}
+
+// asyncSendSlots returns the semaphore limiting the number of messages sent
+// at the same time by SendAsync.
+func (p *Pushover) asyncSendSlots() chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.asyncSlots == nil {
+		p.asyncSlots = make(chan struct{}, concurrentRequests)
+	}
+
+	return p.asyncSlots
+}
+
+// Notify sends a simple message to the default recipient of the app.
+func (p *Pushover) Notify(text string) (*Response, error) {
+	return p.SendMessage(NewMessage(text), p.DefaultRecipient())
 }
 
 // SendGlanceUpdate is used to send glance updates to a recipient.
 // It can be used to display widgets on a smart watch
 func (p *Pushover) SendGlanceUpdate(msg *Glance, rec *Recipient) (*Response, error) {
+	return p.sendGlanceUpdate(msg, rec)
+}
+
+// SendGlance sends a glance update to a recipient, it's the same as
+// SendGlanceUpdate.
+func (p *Pushover) SendGlance(glance *Glance, recipient *Recipient) (*Response, error) {
+	return p.SendGlanceUpdate(glance, recipient)
+}
+
+// sendGlanceUpdate validates and sends a glance update.
+func (p *Pushover) sendGlanceUpdate(msg *Glance, rec *Recipient) (*Response, error) {
 	// Validate pushover
 	if err := p.validate(); err != nil {
 		return nil, err
@@ -153,32 +1086,56 @@ func (p *Pushover) SendGlanceUpdate(msg *Glance, rec *Recipient) (*Response, err
 		return nil, err
 	}
 
+	if p.lowercaseDevices() {
+		lowered := *msg
+		lowered.DeviceName = strings.ToLower(lowered.DeviceName)
+		msg = &lowered
+	}
+
 	// Validate msg
 	if err := msg.validate(); err != nil {
 		return nil, err
 	}
 
-	return msg.send(p.token, rec.token)
+	if c := p.glanceCoalescer(); c != nil {
+		return c.submit(msg, rec)
+	}
+
+	return p.sendGlance(msg, rec)
+}
+
+// sendGlance sends a validated glance update.
+func (p *Pushover) sendGlance(msg *Glance, rec *Recipient) (*Response, error) {
+	req, err := msg.request(p.Endpoint(), p.token, rec.token)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &Response{}
+	if err := p.do(req.WithContext(withAuditDetails(context.Background(), nil, rec)), response, true); err != nil {
+		return nil, err
+	}
+	p.cacheLimit(response)
+
+	return response, nil
 }
 
 // GetReceiptDetails return detailed information about a receipt. This is used
-// used to check the acknowledged status of an Emergency notification.
+// used to check the acknowledged status of an Emergency notification. An
+// APIError is returned if the receipt is unknown or expired.
 func (p *Pushover) GetReceiptDetails(receipt string) (*ReceiptDetails, error) {
-	url := fmt.Sprintf("%s/receipts/%s.json?token=%s", APIEndpoint, receipt, p.token)
+	url := fmt.Sprintf("%s/receipts/%s.json?token=%s", p.Endpoint(), receipt, p.token)
 
 	if receipt == "" {
 		return nil, ErrEmptyReceipt
 	}
 
-	// Send request
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
+	newRequest := func() (*http.Request, error) {
+		return http.NewRequest("GET", url, nil)
 	}
 
-	// Decode the JSON response
-	var details *ReceiptDetails
-	if err = json.NewDecoder(resp.Body).Decode(&details); err != nil {
+	details := &ReceiptDetails{}
+	if _, _, err := p.doRetry(context.Background(), newRequest, details, false); err != nil {
 		return nil, err
 	}
 
@@ -190,7 +1147,13 @@ func (p *Pushover) GetReceiptDetails(receipt string) (*ReceiptDetails, error) {
 // RecipientDetails object will contain an error if the recipient is not valid
 // in the Pushover API.
 func (p *Pushover) GetRecipientDetails(recipient *Recipient) (*RecipientDetails, error) {
-	endpoint := fmt.Sprintf("%s/users/validate.json", APIEndpoint)
+	return p.getRecipientDetails(context.Background(), recipient)
+}
+
+// getRecipientDetails returns the details of a recipient, the request is
+// canceled with the context.
+func (p *Pushover) getRecipientDetails(ctx context.Context, recipient *Recipient) (*RecipientDetails, error) {
+	endpoint := fmt.Sprintf("%s/users/validate.json", p.Endpoint())
 
 	// Validate pushover
 	if err := p.validate(); err != nil {
@@ -209,7 +1172,7 @@ func (p *Pushover) GetRecipientDetails(recipient *Recipient) (*RecipientDetails,
 	}
 
 	var response RecipientDetails
-	if err := do(req, &response, false); err != nil {
+	if err := p.do(req.WithContext(withAuditDetails(ctx, nil, recipient)), &response, false); err != nil {
 		return nil, err
 	}
 
@@ -220,7 +1183,38 @@ func (p *Pushover) GetRecipientDetails(recipient *Recipient) (*RecipientDetails,
 // notification with an Emergency priority before reaching the expiration time.
 // It requires the response receipt in order to stop the right notification.
 func (p *Pushover) CancelEmergencyNotification(receipt string) (*Response, error) {
-	endpoint := fmt.Sprintf("%s/receipts/%s/cancel.json", APIEndpoint, receipt)
+	endpoint := fmt.Sprintf("%s/receipts/%s/cancel.json", p.Endpoint(), receipt)
+
+	req, err := newURLEncodedRequest("POST", endpoint, map[string]string{"token": p.token})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &Response{}
+	if err := p.do(req, response, false); err != nil {
+		return nil, err
+	}
+
+	// The notification is not pending anymore
+	if store := p.ReceiptStore(); store != nil {
+		if err := store.Delete(receipt); err != nil {
+			return response, err
+		}
+	}
+
+	return response, nil
+}
+
+// CancelEmergencyNotificationByTag stops the retries of all the emergency
+// notifications sent with the given tag. The Canceled field of the response
+// is the number of notifications canceled. The canceled receipts are not
+// known, they are left in the receipt store.
+func (p *Pushover) CancelEmergencyNotificationByTag(tag string) (*Response, error) {
+	endpoint := fmt.Sprintf("%s/receipts/cancel_by_tag/%s.json", p.Endpoint(), url.PathEscape(tag))
+
+	if tag == "" {
+		return nil, ErrEmptyTag
+	}
 
 	req, err := newURLEncodedRequest("POST", endpoint, map[string]string{"token": p.token})
 	if err != nil {
@@ -228,7 +1222,7 @@ func (p *Pushover) CancelEmergencyNotification(receipt string) (*Response, error
 	}
 
 	response := &Response{}
-	if err := do(req, response, false); err != nil {
+	if err := p.do(req, response, false); err != nil {
 		return nil, err
 	}
 
@@ -1,10 +1,18 @@
 package pushover
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -99,7 +107,7 @@ func TestValidPostForm(t *testing.T) {
 	}
 
 	got := &Response{}
-	if err := do(req, got, true); err != nil {
+	if err := fakePushover.do(req, got, true); err != nil {
 		t.Fatalf("failed to do request: %v", err)
 	}
 
@@ -133,15 +141,29 @@ func TestPostFormErrors(t *testing.T) {
 	}
 
 	got := &Response{}
-	err = do(req, got, true)
+	err = fakePushover.do(req, got, true)
 	if err == nil {
 		t.Fatalf("expected an error, got nil")
 	}
 
-	expected := Errors{"error1", "error2"}
+	expected := &APIError{
+		RequestID:  "e460545a8b333d0da2f3602aff3133d6",
+		StatusCode: http.StatusOK,
+		Errors:     Errors{"error1", "error2"},
+	}
 	if reflect.DeepEqual(err, expected) == false {
 		t.Errorf("failed to get postFormErrors")
 	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.RequestID != got.ID {
+		t.Errorf("expected an APIError with the request ID, got %v", err)
+	}
+
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Errorf("expected the errors of the API, got %v", err)
+	}
 }
 
 // TestGetRecipientDetails
@@ -151,8 +173,8 @@ func TestGetRecipientDetails(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	APIEndpoint = ts.URL
-	got, err := fakePushover.GetRecipientDetails(fakeRecipient)
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+	got, err := app.GetRecipientDetails(fakeRecipient)
 	if err != nil {
 		t.Fatalf("expected no error, got %q", err)
 	}
@@ -177,8 +199,8 @@ func TestGetRecipientDetailsError(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	APIEndpoint = ts.URL
-	got, err := fakePushover.GetRecipientDetails(fakeRecipient)
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+	got, err := app.GetRecipientDetails(fakeRecipient)
 	if err != nil {
 		t.Fatalf("expected no error, got %q", err)
 	}
@@ -214,8 +236,8 @@ func TestGetReceiptDetails(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	APIEndpoint = ts.URL
-	got, err := fakePushover.GetReceiptDetails("fasdfadfasdfadfaf")
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+	got, err := app.GetReceiptDetails("fasdfadfasdfadfaf")
 	if err != nil {
 		t.Fatalf("expected no error, got %q", err)
 	}
@@ -251,8 +273,7 @@ func TestCancelEmergencyNotification(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	APIEndpoint = ts.URL
-	p := &Pushover{}
+	p := &Pushover{endpoint: ts.URL}
 	got, err := p.CancelEmergencyNotification("fasfaasdfa")
 	if err != nil {
 		t.Fatalf("expected no error, got %q", err)
@@ -281,8 +302,8 @@ func TestSendMessage(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	APIEndpoint = ts.URL
-	got, err := fakePushover.SendMessage(NewMessage("TestMessage"), fakeRecipient)
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+	got, err := app.SendMessage(NewMessage("TestMessage"), fakeRecipient)
 	if err != nil {
 		t.Fatalf("expected no error, got %q", err)
 	}
@@ -297,9 +318,855 @@ func TestSendMessage(t *testing.T) {
 			Remaining: 6000,
 			NextReset: time.Unix(int64(1393653600), 0),
 		},
+		Attempts: 1,
 	}
 
 	if reflect.DeepEqual(got, expected) == false {
 		t.Errorf("unexpected response from postFrom")
 	}
 }
+
+// TestSendMessageToMultipleCallbackURLFunc tests the callback URL is computed
+// for each recipient of a batch send
+func TestSendMessageToMultipleCallbackURLFunc(t *testing.T) {
+	var callback string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callback = r.FormValue("callback")
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6","receipt":"rLqVuqTRh62UzxtmqiaLzQmVcPgiCy"}`)
+	}))
+	defer ts.Close()
+
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+
+	tt := []struct {
+		name        string
+		callback    string
+		expectedErr error
+	}{
+		{"valid callback", "http://yourapp.com/callback/" + fakeRecipient.token, nil},
+		{"invalid callback", "yourapp.com/callback", ErrInvalidCallbackURL},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			message := &Message{
+				Message:  "Test message",
+				Priority: PriorityEmergency,
+				Expire:   time.Hour,
+				Retry:    60 * time.Second,
+			}
+			opt := WithCallbackURLFunc(func(r *Recipient) string {
+				return tc.callback
+			})
+
+			callback = ""
+			_, errs := app.SendMessageToMultiple(context.Background(), message, []*Recipient{fakeRecipient}, opt)
+			if errs[0] != tc.expectedErr {
+				t.Fatalf("expected %v, got %v", tc.expectedErr, errs[0])
+			}
+
+			if tc.expectedErr != nil {
+				return
+			}
+
+			if callback != tc.callback {
+				t.Errorf("expected callback %q, got %q", tc.callback, callback)
+			}
+
+			if message.CallbackURL != "" {
+				t.Errorf("the message should not be modified")
+			}
+		})
+	}
+}
+
+// TestSendMessageToMultipleFormatFunc tests the format is computed for each
+// recipient of a batch send
+func TestSendMessageToMultipleFormatFunc(t *testing.T) {
+	var html, monospace string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		html = r.FormValue("html")
+		monospace = r.FormValue("monospace")
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+
+	tt := []struct {
+		name              string
+		format            MessageFormat
+		expectedHTML      string
+		expectedMonospace string
+		expectedErr       error
+	}{
+		{"plain", FormatPlain, "", "", nil},
+		{"html", FormatHTML, "1", "", nil},
+		{"monospace", FormatMonospace, "", "1", nil},
+		{"invalid format", MessageFormat(42), "", "", ErrInvalidMessageFormat},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			message := &Message{
+				Message: "Test message",
+				HTML:    true,
+			}
+			opt := WithFormatFunc(func(r *Recipient) MessageFormat {
+				return tc.format
+			})
+
+			html, monospace = "", ""
+			responses, errs := app.SendMessageToMultiple(context.Background(), message, []*Recipient{fakeRecipient}, opt)
+			response, err := responses[0], errs[0]
+			if err != tc.expectedErr {
+				t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if err == nil && (response.HTMLSent != (tc.expectedHTML == "1") || response.MonospaceSent != (tc.expectedMonospace == "1")) {
+				t.Errorf("unexpected format flags in the response: html=%t monospace=%t",
+					response.HTMLSent, response.MonospaceSent)
+			}
+
+			if html != tc.expectedHTML || monospace != tc.expectedMonospace {
+				t.Errorf("expected html=%q monospace=%q, got html=%q monospace=%q",
+					tc.expectedHTML, tc.expectedMonospace, html, monospace)
+			}
+
+			if !message.HTML || message.Monospace {
+				t.Errorf("the message should not be modified")
+			}
+		})
+	}
+}
+
+// TestPreflightSend tests the message validation and the quota check
+func TestPreflightSend(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "0")
+		w.Header().Set("X-Limit-App-Reset", fmt.Sprintf("%d", time.Now().Add(time.Hour).Unix()))
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+	ctx := context.Background()
+
+	if err := app.PreflightSend(ctx, &Message{}, fakeRecipient); err != ErrMessageEmpty {
+		t.Fatalf("expected %v, got %v", ErrMessageEmpty, err)
+	}
+
+	// Nothing is known about the quota yet
+	if err := app.PreflightSend(ctx, NewMessage("Hello"), fakeRecipient); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// The last message sent uses the remaining quota
+	if _, err := app.SendMessage(NewMessage("Hello"), fakeRecipient); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := app.PreflightSend(ctx, NewMessage("Hello"), fakeRecipient); err != ErrQuotaExhausted {
+		t.Fatalf("expected %v, got %v", ErrQuotaExhausted, err)
+	}
+}
+
+// TestSendBestEffortMessage tests the best effort messages are skipped when
+// the quota is low
+func TestSendBestEffortMessage(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "10")
+		w.Header().Set("X-Limit-App-Reset", fmt.Sprintf("%d", time.Now().Add(time.Hour).Unix()))
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+	app.SetQuotaReserve(10)
+
+	message := NewMessage("Hello")
+	message.BestEffort = true
+
+	// The quota is unknown before the first message
+	for _, expectedSkipped := range []bool{false, true} {
+		response, err := app.SendMessage(message, fakeRecipient)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if response.Skipped != expectedSkipped {
+			t.Errorf("expected skipped to be %t", expectedSkipped)
+		}
+	}
+
+	// Other messages are still sent
+	if _, err := app.SendMessage(NewMessage("Hello"), fakeRecipient); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+}
+
+// TestSendMessageQuotaExhausted tests that no request is sent once the quota
+// is exhausted
+func TestSendMessageQuotaExhausted(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "0")
+		w.Header().Set("X-Limit-App-Reset", fmt.Sprintf("%d", time.Now().Add(time.Hour).Unix()))
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+
+	if _, err := app.SendMessage(NewMessage("Hello"), fakeRecipient); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := app.SendMessage(NewMessage("Hello"), fakeRecipient); err != ErrQuotaExhausted {
+		t.Fatalf("expected %v, got %v", ErrQuotaExhausted, err)
+	}
+
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+}
+
+// TestSendMessageLowercaseDevices tests the device names are lowercased when
+// enabled
+func TestSendMessageLowercaseDevices(t *testing.T) {
+	var device string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		device = r.FormValue("device")
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+
+	message := NewMessage("Hello")
+	message.DeviceName = "MyPhone,Tablet"
+
+	if _, err := app.SendMessage(message, fakeRecipient); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if device != "MyPhone,Tablet" {
+		t.Errorf("expected the device to be unchanged, got %q", device)
+	}
+
+	app.SetLowercaseDevices(true)
+	if _, err := app.SendMessage(message, fakeRecipient); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if device != "myphone,tablet" {
+		t.Errorf("expected the device to be lowercased, got %q", device)
+	}
+
+	// The message of the caller is left untouched
+	if message.DeviceName != "MyPhone,Tablet" {
+		t.Errorf("expected the message to be unchanged, got %q", message.DeviceName)
+	}
+}
+
+// TestSendMessageContext tests the context reaches the HTTP request
+func TestSendMessageContext(t *testing.T) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := app.SendMessageContext(ctx, NewMessage("Hello"), fakeRecipient)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+// TestEndpoint tests each app uses its own endpoint
+func TestEndpoint(t *testing.T) {
+	var requests [2]int
+	servers := make([]*httptest.Server, 2)
+	for i := range servers {
+		i := i
+		servers[i] = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests[i]++
+			fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+		}))
+		defer servers[i].Close()
+	}
+
+	first := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(servers[0].URL))
+	second := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(servers[1].URL))
+
+	for _, app := range []*Pushover{first, second, second} {
+		if _, err := app.GetRecipientDetails(fakeRecipient); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if requests != [2]int{1, 2} {
+		t.Errorf("expected requests %v, got %v", [2]int{1, 2}, requests)
+	}
+
+	if got := (&Pushover{}).Endpoint(); got != DefaultAPIEndpoint {
+		t.Errorf("expected endpoint %q, got %q", DefaultAPIEndpoint, got)
+	}
+}
+
+// TestSendMessageMaxRequestSize tests the requests too large are not sent
+func TestSendMessageMaxRequestSize(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+	app.SetMaxRequestSize(1024)
+
+	if _, err := app.SendMessage(NewMessage("Hello"), fakeRecipient); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	message := NewMessage("Hello")
+	if err := message.AddAttachment(bytes.NewReader(make([]byte, 2048))); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := app.SendMessage(message, fakeRecipient); !errors.Is(err, ErrRequestTooLarge) {
+		t.Fatalf("expected %v, got %v", ErrRequestTooLarge, err)
+	}
+
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+}
+
+// TestSetTokenPattern tests the token pattern can be changed
+func TestSetTokenPattern(t *testing.T) {
+	app := New("aQiRzpo4DXghDmr9QzzfQu27cmVRsG-v2")
+	if err := app.validate(); err != ErrInvalidToken {
+		t.Fatalf("expected %v, got %v", ErrInvalidToken, err)
+	}
+
+	if err := app.SetTokenPattern(`^[A-Za-z0-9-]{30,40}$`); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := app.validate(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := app.SetTokenPattern(`[`); err == nil {
+		t.Fatal("expected an error with an invalid pattern")
+	}
+}
+
+// TestUnusualTokenWarning tests a warning is logged once for the unusual
+// tokens
+func TestUnusualTokenWarning(t *testing.T) {
+	buf := &bytes.Buffer{}
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG")
+	app.SetLogger(log.New(buf, "", 0))
+
+	for i := 0; i < 2; i++ {
+		if err := app.validate(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if got := strings.Count(buf.String(), "unusual app token"); got != 1 {
+		t.Errorf("expected 1 warning, got %d: %q", got, buf.String())
+	}
+}
+
+// TestConcurrentSendMessage tests an app can be shared between goroutines,
+// it's meant to be run with the race detector
+func TestConcurrentSendMessage(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6","receipt":"rLqVuqTRh62UzxtmqiaLzQmVcPgiCy"}`)
+	}))
+	defer ts.Close()
+
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+	app.SetReceiptStore(NewMemoryReceiptStore())
+	app.SetGlobalRate(1000, time.Second)
+	app.SetAuditSink(AuditSinkFunc(func(*AuditEvent) {}))
+
+	// The message is shared as well
+	message := NewMessage("Hello :fire:")
+	if err := message.AddAttachment(strings.NewReader("data")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := app.SendMessage(message, fakeRecipient); err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			app.SetEmojiShortcodes(i%2 == 0)
+			app.SetQuotaReserve(i)
+			app.SetLowercaseDevices(i%2 == 0)
+			app.RemainingMessages()
+			app.Stats()
+		}(i)
+	}
+	wg.Wait()
+
+	if got := app.Stats().Sent; got != 10 {
+		t.Errorf("expected 10 messages sent, got %d", got)
+	}
+}
+
+// TestCancelEmergencyNotificationByTag tests the number of notifications
+// canceled is returned
+func TestCancelEmergencyNotificationByTag(t *testing.T) {
+	var path string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		fmt.Fprintln(w, `{"status":1,"canceled":3,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+	got, err := app.CancelEmergencyNotificationByTag("incident-42")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if path != "/receipts/cancel_by_tag/incident-42.json" {
+		t.Errorf("unexpected path %q", path)
+	}
+
+	if got.Canceled != 3 {
+		t.Errorf("expected 3 notifications canceled, got %d", got.Canceled)
+	}
+
+	// The tag is escaped
+	if _, err := app.CancelEmergencyNotificationByTag("db/primary"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if path != "/receipts/cancel_by_tag/db%2Fprimary.json" {
+		t.Errorf("unexpected path %q", path)
+	}
+
+	if _, err := app.CancelEmergencyNotificationByTag(""); err != ErrEmptyTag {
+		t.Fatalf("expected %v, got %v", ErrEmptyTag, err)
+	}
+}
+
+// TestSendAsync tests the results of the async sends are given to the
+// callback and Flush waits for them
+func TestSendAsync(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+
+	var mu sync.Mutex
+	var results []error
+	done := func(r *Response, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		results = append(results, err)
+	}
+
+	app.SendAsync(NewMessage("Hello"), fakeRecipient, done)
+	app.SendAsync(NewMessage(""), fakeRecipient, done)
+	app.SendAsync(NewMessage("Hello"), fakeRecipient, nil)
+
+	if err := app.Flush(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+
+	var failures int
+	for _, err := range results {
+		if errors.Is(err, ErrMessageEmpty) {
+			failures++
+		}
+	}
+	if failures != 1 {
+		t.Errorf("expected 1 failure, got %d", failures)
+	}
+
+	if got := app.Stats().Sent; got != 2 {
+		t.Errorf("expected 2 messages sent, got %d", got)
+	}
+}
+
+// TestSendMessageDeviceFallback tests the message is sent to all the devices
+// when its device is not found
+func TestSendMessageDeviceFallback(t *testing.T) {
+	var devices []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		device := r.FormValue("device")
+		devices = append(devices, device)
+		if device != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, `{"device":"invalid","errors":["device name is not valid for user"],"status":0,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+			return
+		}
+
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+	message := &Message{Message: "Hello", DeviceName: "renamed"}
+
+	if _, err := app.SendMessage(message, fakeRecipient); err == nil {
+		t.Fatalf("expected an error without the fallback")
+	}
+
+	devices = nil
+	app.SetDeviceFallback(true)
+	response, err := app.SendMessage(message, fakeRecipient)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !response.DeviceFallback {
+		t.Errorf("expected the device fallback to be used")
+	}
+
+	if !reflect.DeepEqual(devices, []string{"renamed", ""}) {
+		t.Errorf("unexpected devices %q", devices)
+	}
+
+	if message.DeviceName != "renamed" {
+		t.Errorf("expected the message to be left unchanged")
+	}
+}
+
+// TestSendMessageToMultiple tests the results are in the order of the
+// recipients and the attachment is sent to each of them
+func TestSendMessageToMultiple(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("attachment")
+		if err != nil {
+			t.Errorf("expected an attachment, got %v", err)
+			return
+		}
+		defer file.Close()
+
+		var data bytes.Buffer
+		if _, err := data.ReadFrom(file); err != nil || data.String() != "data" {
+			t.Errorf("unexpected attachment %q, %v", data.String(), err)
+		}
+
+		if r.FormValue("user") == "uQiRzpo4DXghDmr9QzzfQu27cmVRsG" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, `{"user":"invalid","errors":["user identifier is invalid"],"status":0,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+			return
+		}
+
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+	message := NewMessage("Hello")
+	if err := message.AddAttachment(strings.NewReader("data")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var recipients []*Recipient
+	for i := 0; i < 10; i++ {
+		recipients = append(recipients, fakeRecipient)
+	}
+	recipients[3] = NewRecipient("uQiRzpo4DXghDmr9QzzfQu27cmVRsG")
+	recipients[7] = NewRecipient("invalid")
+
+	responses, errs := app.SendMessageToMultiple(context.Background(), message, recipients)
+	if len(responses) != len(recipients) || len(errs) != len(recipients) {
+		t.Fatalf("expected %d results, got %d responses and %d errors", len(recipients), len(responses), len(errs))
+	}
+
+	for i := range recipients {
+		switch i {
+		case 3:
+			var apiErr *APIError
+			if !errors.As(errs[i], &apiErr) {
+				t.Errorf("expected an APIError for the recipient %d, got %v", i, errs[i])
+			}
+		case 7:
+			if errs[i] != ErrInvalidRecipientToken {
+				t.Errorf("expected %v for the recipient %d, got %v", ErrInvalidRecipientToken, i, errs[i])
+			}
+		default:
+			if errs[i] != nil || responses[i] == nil {
+				t.Errorf("expected a response for the recipient %d, got %v", i, errs[i])
+			}
+		}
+	}
+
+	if got := app.Stats().Sent; got != 8 {
+		t.Errorf("expected 8 messages sent, got %d", got)
+	}
+}
+
+// TestSendMessageToMultipleEmptyRecipients tests the nil and empty
+// recipients are skipped by default and abort the batch with WithFailFast
+func TestSendMessageToMultipleEmptyRecipients(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	recipients := []*Recipient{fakeRecipient, nil, NewRecipient(""), fakeRecipient}
+
+	tt := []struct {
+		name         string
+		opts         []BatchOption
+		expectedErrs []error
+		expectedSent int64
+	}{
+		{"skip", nil, []error{nil, ErrInvalidRecipient, ErrInvalidRecipient, nil}, 2},
+		{"fail fast", []BatchOption{WithFailFast()}, []error{ErrBatchAborted, ErrInvalidRecipient, ErrInvalidRecipient, ErrBatchAborted}, 0},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+			responses, errs := app.SendMessageToMultiple(context.Background(), NewMessage("Hello"), recipients, tc.opts...)
+			if !reflect.DeepEqual(errs, tc.expectedErrs) {
+				t.Errorf("expected errors %v, got %v", tc.expectedErrs, errs)
+			}
+
+			for i, err := range errs {
+				if (err == nil) != (responses[i] != nil) {
+					t.Errorf("unexpected response %v for the recipient %d", responses[i], i)
+				}
+			}
+
+			if got := app.Stats().Sent; got != tc.expectedSent {
+				t.Errorf("expected %d messages sent, got %d", tc.expectedSent, got)
+			}
+		})
+	}
+}
+
+// chunkReader records the size of the largest read.
+type chunkReader struct {
+	r       io.Reader
+	maxRead int
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	if len(p) > c.maxRead {
+		c.maxRead = len(p)
+	}
+
+	return c.r.Read(p)
+}
+
+// TestSetUploadBufferSize tests the attachments are read in chunks of the
+// upload buffer size
+func TestSetUploadBufferSize(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	tt := []struct {
+		name     string
+		size     int
+		expected int
+	}{
+		{"default size", 0, DefaultUploadBufferSize},
+		{"custom size", 512, 512},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+			app.SetUploadBufferSize(tc.size)
+
+			r := &chunkReader{r: bytes.NewReader(make([]byte, 100*1024))}
+			message := NewMessage("Hello")
+			if err := message.AddAttachment(r); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if _, err := app.SendMessage(message, fakeRecipient); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if r.maxRead != tc.expected {
+				t.Errorf("expected reads of %d bytes, got %d", tc.expected, r.maxRead)
+			}
+		})
+	}
+}
+
+// TestSendMessageAttachmentTwice tests the attachment is sent each time the
+// message is sent
+func TestSendMessageAttachmentTwice(t *testing.T) {
+	var attachments []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("attachment")
+		if err != nil {
+			t.Errorf("expected an attachment, got %v", err)
+			return
+		}
+		defer file.Close()
+
+		var data bytes.Buffer
+		if _, err := data.ReadFrom(file); err != nil {
+			t.Errorf("failed to read the attachment: %v", err)
+		}
+		attachments = append(attachments, data.String())
+
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+	message := NewMessage("Hello")
+	if err := message.AddAttachmentReader("data.txt", "text/plain", strings.NewReader("data")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := app.SendMessage(message, fakeRecipient); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if !reflect.DeepEqual(attachments, []string{"data", "data"}) {
+		t.Errorf("expected the attachment to be sent twice, got %q", attachments)
+	}
+}
+
+// TestVersion tests the version is part of the default user agent
+func TestVersion(t *testing.T) {
+	if !regexp.MustCompile(`^\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?$`).MatchString(Version) {
+		t.Errorf("invalid version %q", Version)
+	}
+
+	if !strings.HasSuffix(DefaultUserAgent, "/"+Version) {
+		t.Errorf("expected the version in the user agent, got %q", DefaultUserAgent)
+	}
+}
+
+// TestSendAsyncFlushConcurrently tests the messages can be sent while
+// flushing and at most concurrentRequests are sent at the same time
+func TestSendAsyncFlushConcurrently(t *testing.T) {
+	var mu sync.Mutex
+	var current, max int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		current++
+		if current > max {
+			max = current
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		current--
+		mu.Unlock()
+
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+
+	var done sync.WaitGroup
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		done.Add(1)
+		go func() {
+			defer wg.Done()
+			app.SendAsync(NewMessage("Hello"), fakeRecipient, func(*Response, error) { done.Done() })
+		}()
+		go func() {
+			defer wg.Done()
+			if err := app.Flush(context.Background()); err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := app.Flush(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	done.Wait()
+
+	if got := app.Stats().Sent; got != 20 {
+		t.Errorf("expected 20 messages sent, got %d", got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if max > concurrentRequests {
+		t.Errorf("expected at most %d concurrent sends, got %d", concurrentRequests, max)
+	}
+}
@@ -0,0 +1,67 @@
+package pushover
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket allowing n requests per period.
+type rateLimiter struct {
+	n   int
+	per time.Duration
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(n int, per time.Duration, now time.Time) *rateLimiter {
+	return &rateLimiter{
+		n:      n,
+		per:    per,
+		tokens: float64(n),
+		last:   now,
+	}
+}
+
+// reserve takes a token from the bucket and returns the delay to wait before
+// using it. If wait is false no token is taken when the bucket is empty and
+// ErrRateLimited is returned.
+func (l *rateLimiter) reserve(now time.Time, wait bool) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Refill the bucket
+	l.tokens += float64(now.Sub(l.last)) * float64(l.n) / float64(l.per)
+	if l.tokens > float64(l.n) {
+		l.tokens = float64(l.n)
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0, nil
+	}
+
+	if !wait {
+		return 0, ErrRateLimited
+	}
+
+	// Take the token in advance, the next callers will wait longer
+	delay := time.Duration((1 - l.tokens) * float64(l.per) / float64(l.n))
+	l.tokens--
+
+	return delay, nil
+}
+
+// release gives back a token taken by reserve and not used, e.g. when the
+// wait was canceled.
+func (l *rateLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens++
+	if l.tokens > float64(l.n) {
+		l.tokens = float64(l.n)
+	}
+}
@@ -0,0 +1,100 @@
+package pushover
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestRateLimiter tests the token bucket
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	l := newRateLimiter(2, time.Second, now)
+
+	for i := 0; i < 2; i++ {
+		if delay, err := l.reserve(now, false); err != nil || delay != 0 {
+			t.Fatalf("expected no delay, got %s, %v", delay, err)
+		}
+	}
+
+	if _, err := l.reserve(now, false); err != ErrRateLimited {
+		t.Fatalf("expected %v, got %v", ErrRateLimited, err)
+	}
+
+	delay, err := l.reserve(now, true)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if delay != 500*time.Millisecond {
+		t.Errorf("expected a delay of 500ms, got %s", delay)
+	}
+
+	// The bucket is refilled over time
+	if delay, err := l.reserve(now.Add(time.Second), false); err != nil || delay != 0 {
+		t.Fatalf("expected no delay, got %s, %v", delay, err)
+	}
+}
+
+// TestSendMessageGlobalRate tests the global rate applies to the messages
+func TestSendMessageGlobalRate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+	app.SetGlobalRate(1, 50*time.Millisecond)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := app.SendMessage(NewMessage("Hello"), fakeRecipient); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("expected the sends to be throttled, took %s", elapsed)
+	}
+
+	app.SetGlobalRateBlocking(false)
+	if _, err := app.SendMessage(NewMessage("Hello"), fakeRecipient); err != ErrRateLimited {
+		t.Fatalf("expected %v, got %v", ErrRateLimited, err)
+	}
+}
+
+// TestRateLimiterCanceledWait tests the token reserved by a canceled wait is
+// given back
+func TestRateLimiterCanceledWait(t *testing.T) {
+	clock := &manualClock{now: time.Unix(1393653600, 0)}
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG")
+	app.SetClock(clock)
+	app.SetGlobalRate(1, time.Second)
+
+	if err := app.waitGlobalRate(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 3; i++ {
+		if err := app.waitGlobalRate(ctx); err != context.Canceled {
+			t.Fatalf("expected %v, got %v", context.Canceled, err)
+		}
+	}
+
+	// The canceled waits don't delay the next callers
+	delay, err := app.rate.reserve(clock.Now(), true)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if delay != time.Second {
+		t.Errorf("expected a delay of 1s, got %s", delay)
+	}
+}
@@ -9,16 +9,18 @@ import (
 // ReceiptDetails represents the receipt informations in case of emergency
 // priority.
 type ReceiptDetails struct {
-	Status          int
-	Acknowledged    bool
-	AcknowledgedBy  string
-	Expired         bool
-	CalledBack      bool
-	ID              string
-	AcknowledgedAt  *time.Time
-	LastDeliveredAt *time.Time
-	ExpiresAt       *time.Time
-	CalledBackAt    *time.Time
+	Status               int
+	Acknowledged         bool
+	AcknowledgedBy       string
+	AcknowledgedByDevice string
+	Expired              bool
+	CalledBack           bool
+	ID                   string
+	AcknowledgedAt       *time.Time
+	LastDeliveredAt      *time.Time
+	ExpiresAt            *time.Time
+	CalledBackAt         *time.Time
+	Errors               Errors
 }
 
 // UnmarshalJSON is a custom unmarshal function to handle timestamps and
@@ -26,16 +28,18 @@ type ReceiptDetails struct {
 func (r *ReceiptDetails) UnmarshalJSON(data []byte) error {
 	dataBytes := bytes.NewReader(data)
 	var aux struct {
-		ID              string     `json:"request"`
-		Status          int        `json:"status"`
-		Acknowledged    intBool    `json:"acknowledged"`
-		AcknowledgedBy  string     `json:"acknowledged_by"`
-		Expired         intBool    `json:"expired"`
-		CalledBack      intBool    `json:"called_back"`
-		AcknowledgedAt  *timestamp `json:"acknowledged_at"`
-		LastDeliveredAt *timestamp `json:"last_delivered_at"`
-		ExpiresAt       *timestamp `json:"expires_at"`
-		CalledBackAt    *timestamp `json:"called_back_at"`
+		ID                   string    `json:"request"`
+		Status               int       `json:"status"`
+		Acknowledged         intBool   `json:"acknowledged"`
+		AcknowledgedBy       string    `json:"acknowledged_by"`
+		AcknowledgedByDevice string    `json:"acknowledged_by_device"`
+		Expired              intBool   `json:"expired"`
+		CalledBack           intBool   `json:"called_back"`
+		AcknowledgedAt       timestamp `json:"acknowledged_at"`
+		LastDeliveredAt      timestamp `json:"last_delivered_at"`
+		ExpiresAt            timestamp `json:"expires_at"`
+		CalledBackAt         timestamp `json:"called_back_at"`
+		Errors               Errors    `json:"errors"`
 	}
 
 	// Decode json into the aux struct
@@ -47,6 +51,7 @@ func (r *ReceiptDetails) UnmarshalJSON(data []byte) error {
 	r.Status = aux.Status
 	r.Acknowledged = bool(aux.Acknowledged)
 	r.AcknowledgedBy = aux.AcknowledgedBy
+	r.AcknowledgedByDevice = aux.AcknowledgedByDevice
 	r.Expired = bool(aux.Expired)
 	r.CalledBack = bool(aux.CalledBack)
 	r.ID = aux.ID
@@ -54,6 +59,22 @@ func (r *ReceiptDetails) UnmarshalJSON(data []byte) error {
 	r.LastDeliveredAt = aux.LastDeliveredAt.Time
 	r.ExpiresAt = aux.ExpiresAt.Time
 	r.CalledBackAt = aux.CalledBackAt.Time
+	r.Errors = aux.Errors
 
 	return nil
 }
+
+// response returns the status of the receipt details so they get checked by
+// do, the API answers with a status 0 for the unknown or expired receipts.
+func (r *ReceiptDetails) response() *Response {
+	return &Response{Status: r.Status, ID: r.ID, Errors: r.Errors}
+}
+
+// GroupAcknowledged returns true if the notification sent to a group has been
+// acknowledged. The API does not track the acknowledgement of each member of
+// a group: the receipt is acknowledged as soon as any member acknowledges it
+// and AcknowledgedBy is the user key of this member. There is no way to know
+// if every member has seen the notification.
+func (r *ReceiptDetails) GroupAcknowledged() bool {
+	return r.Acknowledged
+}
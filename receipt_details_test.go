@@ -1,6 +1,10 @@
 package pushover
 
-import "testing"
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
 
 // TestEmptyReceiptDetails tests if the receipt is empty trying to get details
 func TestEmptyReceiptDetails(t *testing.T) {
@@ -14,3 +18,93 @@ func TestEmptyReceiptDetails(t *testing.T) {
 		t.Errorf("Should get an ErrEmptyReceipt")
 	}
 }
+
+// TestReceiptDetailsTimestamps tests the timestamps can be numbers or strings
+func TestReceiptDetailsTimestamps(t *testing.T) {
+	tt := []struct {
+		name      string
+		timestamp string
+		expected  *time.Time
+		fails     bool
+	}{
+		{"number", `1424305421`, timePtr(time.Unix(1424305421, 0)), false},
+		{"string", `"1424305421"`, timePtr(time.Unix(1424305421, 0)), false},
+		{"zero", `0`, nil, false},
+		{"empty string", `""`, nil, false},
+		{"null", `null`, nil, false},
+		{"invalid string", `"yesterday"`, nil, true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var details ReceiptDetails
+			err := json.Unmarshal([]byte(`{"status":1,"acknowledged_at":`+tc.timestamp+`}`), &details)
+			if tc.fails {
+				if err == nil {
+					t.Fatalf("expected an error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if (details.AcknowledgedAt == nil) != (tc.expected == nil) {
+				t.Fatalf("expected %v, got %v", tc.expected, details.AcknowledgedAt)
+			}
+
+			if tc.expected != nil && !details.AcknowledgedAt.Equal(*tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, details.AcknowledgedAt)
+			}
+		})
+	}
+}
+
+// TestReceiptDetailsCallback tests the device and the callback fields are
+// parsed
+func TestReceiptDetailsCallback(t *testing.T) {
+	var details ReceiptDetails
+	data := `{"status":1,"acknowledged":1,"acknowledged_at":1424305421,"acknowledged_by":"uQiRzpo4DXghDmr9QzzfQu27cmVRsG","acknowledged_by_device":"iphone","called_back":1,"called_back_at":1424305425,"last_delivered_at":0,"request":"e95f35c2d75a100a3719b3764f0c8e47"}`
+	if err := json.Unmarshal([]byte(data), &details); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if details.AcknowledgedByDevice != "iphone" {
+		t.Errorf("expected the device iphone, got %q", details.AcknowledgedByDevice)
+	}
+
+	if !details.CalledBack {
+		t.Errorf("expected the callback to be called")
+	}
+
+	if details.CalledBackAt == nil || !details.CalledBackAt.Equal(time.Unix(1424305425, 0)) {
+		t.Errorf("unexpected callback time %v", details.CalledBackAt)
+	}
+
+	if details.LastDeliveredAt != nil {
+		t.Errorf("expected no delivery time, got %v", details.LastDeliveredAt)
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+// TestReceiptDetailsGroupAcknowledged tests the acknowledgement of the group
+// receipts
+func TestReceiptDetailsGroupAcknowledged(t *testing.T) {
+	var details ReceiptDetails
+	data := `{"status":1,"acknowledged":1,"acknowledged_by":"uQiRzpo4DXghDmr9QzzfQu27cmVRsG"}`
+	if err := json.Unmarshal([]byte(data), &details); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !details.GroupAcknowledged() {
+		t.Error("expected the group to have acknowledged the notification")
+	}
+
+	if (&ReceiptDetails{}).GroupAcknowledged() {
+		t.Error("expected the group not to have acknowledged the notification")
+	}
+}
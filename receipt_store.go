@@ -0,0 +1,89 @@
+package pushover
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// TrackedReceipt represents the receipt of an emergency notification kept in
+// a ReceiptStore.
+type TrackedReceipt struct {
+	Receipt string
+	SentAt  time.Time
+}
+
+// ReceiptStore is used to keep track of the receipts of the emergency
+// notifications sent by the app. It can be backed by a database to keep the
+// receipts across restarts.
+type ReceiptStore interface {
+	// Save stores the receipt.
+	Save(receipt *TrackedReceipt) error
+	// Load returns a stored receipt or ErrReceiptNotFound.
+	Load(receipt string) (*TrackedReceipt, error)
+	// Delete removes a receipt from the store, deleting an unknown receipt
+	// is not an error.
+	Delete(receipt string) error
+	// List returns all the stored receipts.
+	List() ([]*TrackedReceipt, error)
+}
+
+// MemoryReceiptStore is an in-memory ReceiptStore safe for concurrent use.
+type MemoryReceiptStore struct {
+	mu       sync.Mutex
+	receipts map[string]*TrackedReceipt
+}
+
+// NewMemoryReceiptStore returns a new empty in-memory receipt store.
+func NewMemoryReceiptStore() *MemoryReceiptStore {
+	return &MemoryReceiptStore{receipts: map[string]*TrackedReceipt{}}
+}
+
+// Save implements the ReceiptStore interface.
+func (s *MemoryReceiptStore) Save(receipt *TrackedReceipt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.receipts[receipt.Receipt] = receipt
+	return nil
+}
+
+// Load implements the ReceiptStore interface.
+func (s *MemoryReceiptStore) Load(receipt string) (*TrackedReceipt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.receipts[receipt]
+	if !ok {
+		return nil, ErrReceiptNotFound
+	}
+
+	return r, nil
+}
+
+// Delete implements the ReceiptStore interface.
+func (s *MemoryReceiptStore) Delete(receipt string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.receipts, receipt)
+	return nil
+}
+
+// List implements the ReceiptStore interface, the receipts are sorted by
+// sending time.
+func (s *MemoryReceiptStore) List() ([]*TrackedReceipt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ret := make([]*TrackedReceipt, 0, len(s.receipts))
+	for _, r := range s.receipts {
+		ret = append(ret, r)
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].SentAt.Before(ret[j].SentAt)
+	})
+
+	return ret, nil
+}
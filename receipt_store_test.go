@@ -0,0 +1,80 @@
+package pushover
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestMemoryReceiptStore tests the in-memory receipt store
+func TestMemoryReceiptStore(t *testing.T) {
+	store := NewMemoryReceiptStore()
+	now := time.Now()
+
+	for i, receipt := range []string{"receipt2", "receipt1"} {
+		if err := store.Save(&TrackedReceipt{Receipt: receipt, SentAt: now.Add(-time.Duration(i) * time.Minute)}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	list, err := store.List()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(list) != 2 || list[0].Receipt != "receipt1" || list[1].Receipt != "receipt2" {
+		t.Fatalf("unexpected receipts list: %v", list)
+	}
+
+	if _, err := store.Load("receipt1"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := store.Delete("receipt1"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := store.Load("receipt1"); err != ErrReceiptNotFound {
+		t.Fatalf("expected %v, got %v", ErrReceiptNotFound, err)
+	}
+}
+
+// TestReceiptTracking tests that the emergency receipts are tracked
+func TestReceiptTracking(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6","receipt":"rLqVuqTRh62UzxtmqiaLzQmVcPgiCy"}`)
+	}))
+	defer ts.Close()
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+	store := NewMemoryReceiptStore()
+	app.SetReceiptStore(store)
+
+	message := &Message{
+		Message:  "Test message",
+		Priority: PriorityEmergency,
+		Expire:   time.Hour,
+		Retry:    60 * time.Second,
+	}
+
+	response, err := app.SendMessage(message, fakeRecipient)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := store.Load(response.Receipt); err != nil {
+		t.Fatalf("expected the receipt to be tracked, got %v", err)
+	}
+
+	if _, err := app.CancelEmergencyNotification(response.Receipt); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := store.Load(response.Receipt); err != ErrReceiptNotFound {
+		t.Fatalf("expected %v, got %v", ErrReceiptNotFound, err)
+	}
+}
@@ -1,6 +1,13 @@
 package pushover
 
-import "regexp"
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
 
 var recipientRegexp *regexp.Regexp
 
@@ -10,18 +17,59 @@ func init() {
 
 // Recipient represents the a recipient to notify.
 type Recipient struct {
-	token string
+	token  string
+	label  string
+	device string
 }
 
 // NewRecipient is the representation of the recipient to notify.
 func NewRecipient(token string) *Recipient {
-	return &Recipient{token}
+	return &Recipient{token: token}
+}
+
+// NewRecipientWithDevice returns a recipient whose messages are sent to the
+// given device by default, unless they specify their own devices.
+func NewRecipientWithDevice(token, device string) *Recipient {
+	return &Recipient{token: token, device: device}
+}
+
+// Label returns the label of the recipient, see LoadRecipients.
+func (r *Recipient) Label() string {
+	return r.label
+}
+
+// Device returns the default device name of the recipient, see
+// NewRecipientWithDevice and LoadRecipients.
+func (r *Recipient) Device() string {
+	return r.device
+}
+
+// ParseRecipient returns a recipient from a user input. The whitespaces,
+// quotes and brackets pasted around the token are removed and the token is
+// extracted from pushover.net URLs. The returned error wraps the validation
+// error of the token.
+func ParseRecipient(input string) (*Recipient, error) {
+	token := strings.Trim(strings.TrimSpace(input), "\"'`<>()[] ")
+
+	if u, err := url.Parse(token); err == nil && u.Host != "" {
+		token = u.Query().Get("user")
+		if token == "" {
+			token = path.Base(strings.TrimRight(u.Path, "/"))
+		}
+	}
+
+	r := NewRecipient(token)
+	if err := r.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %q", err, input)
+	}
+
+	return r, nil
 }
 
 // Validates recipient token.
 func (r *Recipient) validate() error {
-	// Check empty token
-	if r.token == "" {
+	// Check empty token, a nil recipient is considered empty
+	if r == nil || r.token == "" {
 		return ErrEmptyRecipientToken
 	}
 
@@ -29,6 +77,11 @@ func (r *Recipient) validate() error {
 	if !recipientRegexp.MatchString(r.token) {
 		return ErrInvalidRecipientToken
 	}
+
+	// Check the default device
+	if r.device != "" && !deviceNameRegexp.MatchString(r.device) {
+		return ErrInvalidDeviceName
+	}
 	return nil
 }
 
@@ -38,6 +91,79 @@ type RecipientDetails struct {
 	Status    int      `json:"status"`
 	Group     int      `json:"group"`
 	Devices   []string `json:"devices"`
+	Licenses  []string `json:"licenses"`
 	RequestID string   `json:"request"`
 	Errors    Errors   `json:"errors"`
 }
+
+// InvalidRecipients returns the recipients rejected by the API, or with a
+// malformed token, in the given order. The recipients are checked with
+// ValidateRecipients, an error is returned if one of them can't be checked.
+func (p *Pushover) InvalidRecipients(ctx context.Context, recipients []*Recipient) ([]*Recipient, error) {
+	results, err := p.ValidateRecipients(ctx, recipients...)
+	if err != nil {
+		return nil, err
+	}
+
+	var invalid []*Recipient
+	for _, recipient := range recipients {
+		err := results[recipient]
+		if err == nil {
+			continue
+		}
+
+		if err != ErrInvalidRecipient && recipient.validate() == nil {
+			return nil, err
+		}
+
+		invalid = append(invalid, recipient)
+	}
+
+	return invalid, nil
+}
+
+// ValidateRecipients checks the recipients with the API, a few at a time. The
+// returned map holds the result of each recipient: nil if it's valid,
+// ErrInvalidRecipient if it's rejected by the API, the validation error of
+// its token if malformed, or the error of the request. The context error is
+// returned, and set for the recipients not checked, when it's done.
+func (p *Pushover) ValidateRecipients(ctx context.Context, recipients ...*Recipient) (map[*Recipient]error, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	errs := make([]error, len(recipients))
+	checked := make([]bool, len(recipients))
+	forEach(ctx, len(recipients), func(i int) {
+		errs[i] = p.validateRecipient(ctx, recipients[i])
+		checked[i] = true
+	})
+
+	results := make(map[*Recipient]error, len(recipients))
+	for i, recipient := range recipients {
+		if !checked[i] {
+			errs[i] = ctx.Err()
+		}
+		results[recipient] = errs[i]
+	}
+
+	return results, ctx.Err()
+}
+
+// validateRecipient checks a recipient with the API.
+func (p *Pushover) validateRecipient(ctx context.Context, recipient *Recipient) error {
+	if err := recipient.validate(); err != nil {
+		return err
+	}
+
+	details, err := p.getRecipientDetails(ctx, recipient)
+	if err != nil {
+		return err
+	}
+
+	if details.Status != 1 {
+		return ErrInvalidRecipient
+	}
+
+	return nil
+}
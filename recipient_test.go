@@ -1,6 +1,14 @@
 package pushover
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
 
 // TestRecipientTokenFormat tests the token format
 func TestRecipientTokenFormat(t *testing.T) {
@@ -25,3 +33,165 @@ func TestRecipientTokenFormat(t *testing.T) {
 		})
 	}
 }
+
+// TestRecipientWithDevice tests the device of the recipient is used by
+// default
+func TestRecipientWithDevice(t *testing.T) {
+	tt := []struct {
+		name     string
+		device   string
+		message  *Message
+		expected string
+		err      error
+	}{
+		{"default device", "phone", &Message{Message: "Hello"}, "phone", nil},
+		{"message device", "phone", &Message{Message: "Hello", DeviceName: "tablet"}, "tablet", nil},
+		{"message devices", "phone", &Message{Message: "Hello", Devices: []string{"tablet", "watch"}}, "tablet,watch", nil},
+		{"no default device", "", &Message{Message: "Hello"}, "", nil},
+		{"invalid device", "my^phone", &Message{Message: "Hello"}, "", ErrInvalidDeviceName},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			recipient := NewRecipientWithDevice("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", tc.device)
+			if err := recipient.validate(); err != tc.err {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+
+			if tc.err != nil {
+				return
+			}
+
+			message := tc.message.forRecipient(recipient)
+
+			if got := message.toMap("pToken", recipient.token)["device"]; got != tc.expected {
+				t.Errorf("expected device %q, got %q", tc.expected, got)
+			}
+
+			if tc.device != "" && tc.message.DeviceName == tc.device {
+				t.Errorf("expected the message to be left unchanged")
+			}
+		})
+	}
+}
+
+// TestNilRecipient tests that a nil recipient is handled as an empty one
+func TestNilRecipient(t *testing.T) {
+	var r *Recipient
+	if err := r.validate(); err != ErrEmptyRecipientToken {
+		t.Fatalf("expected %v, got %v", ErrEmptyRecipientToken, err)
+	}
+
+	if _, err := fakePushover.SendMessage(NewMessage("Hello"), nil); err != ErrEmptyRecipientToken {
+		t.Fatalf("expected %v, got %v", ErrEmptyRecipientToken, err)
+	}
+}
+
+// TestParseRecipient tests the parsing of pasted recipients
+func TestParseRecipient(t *testing.T) {
+	tt := []struct {
+		name  string
+		input string
+		err   error
+	}{
+		{"token", "gznej3rKEVAvPUxu9vvNnqpmZpokzF", nil},
+		{"whitespaces", "  gznej3rKEVAvPUxu9vvNnqpmZpokzF\n", nil},
+		{"quotes", `"gznej3rKEVAvPUxu9vvNnqpmZpokzF"`, nil},
+		{"backquotes", "`gznej3rKEVAvPUxu9vvNnqpmZpokzF`", nil},
+		{"URL", "https://pushover.net/users/gznej3rKEVAvPUxu9vvNnqpmZpokzF/", nil},
+		{"URL with query", "https://pushover.net/api?user=gznej3rKEVAvPUxu9vvNnqpmZpokzF", nil},
+		{"empty", "  ", ErrEmptyRecipientToken},
+		{"invalid", "gznej3rKEVAvPUxu9vvNnqpmZ", ErrInvalidRecipientToken},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := ParseRecipient(tc.input)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+
+			if err == nil && r.token != "gznej3rKEVAvPUxu9vvNnqpmZpokzF" {
+				t.Errorf("unexpected token %q", r.token)
+			}
+		})
+	}
+}
+
+// TestInvalidRecipients tests the invalid recipients are returned
+func TestInvalidRecipients(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("user") == "uQiRzpo4DXghDmr9QzzfQu27cmVRsG" {
+			fmt.Fprintln(w, `{"status":0,"request":"e460545a8b333d0da2f3602aff3133d6","errors":["user key is invalid"]}`)
+			return
+		}
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+	rejected := NewRecipient("uQiRzpo4DXghDmr9QzzfQu27cmVRsG")
+	malformed := NewRecipient("invalid")
+	recipients := []*Recipient{fakeRecipient, rejected, malformed}
+
+	got, err := app.InvalidRecipients(context.Background(), recipients)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []*Recipient{rejected, malformed}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+// TestValidateRecipients tests the result of each recipient is returned
+func TestValidateRecipients(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("user") == "uQiRzpo4DXghDmr9QzzfQu27cmVRsG" {
+			fmt.Fprintln(w, `{"status":0,"request":"e460545a8b333d0da2f3602aff3133d6","errors":["user key is invalid"]}`)
+			return
+		}
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+	rejected := NewRecipient("uQiRzpo4DXghDmr9QzzfQu27cmVRsG")
+	malformed := NewRecipient("invalid")
+
+	expected := map[*Recipient]error{
+		rejected:  ErrInvalidRecipient,
+		malformed: ErrInvalidRecipientToken,
+	}
+	for i := 0; i < 10; i++ {
+		expected[NewRecipient("gznej3rKEVAvPUxu9vvNnqpmZpokzF")] = nil
+	}
+
+	var recipients []*Recipient
+	for recipient := range expected {
+		recipients = append(recipients, recipient)
+	}
+
+	got, err := app.ValidateRecipients(context.Background(), recipients...)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	got, err = app.ValidateRecipients(ctx, recipients...)
+	if err != context.Canceled {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+
+	for recipient, err := range got {
+		if err != context.Canceled {
+			t.Errorf("expected %v for %v, got %v", context.Canceled, recipient, err)
+		}
+	}
+}
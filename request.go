@@ -1,15 +1,32 @@
 package pushover
 
 import (
+	"bytes"
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
 )
 
-// do is a generic function to send a request to the API.
-func do(req *http.Request, resType interface{}, returnHeaders bool) error {
-	client := http.DefaultClient
+// do is a generic function to send a request to the API, every request is
+// audited.
+func (p *Pushover) do(req *http.Request, resType interface{}, returnHeaders bool) error {
+	err := p.doRequest(req, resType, returnHeaders)
+	p.auditRequest(req, resType, err)
+	return err
+}
+
+// doRequest sends a request to the API and decodes the response.
+func (p *Pushover) doRequest(req *http.Request, resType interface{}, returnHeaders bool) error {
+	client := p.HTTPClient()
+	req.Header.Set("User-Agent", p.UserAgent())
+
+	if intercept := p.requestInterceptor(); intercept != nil {
+		if err := intercept(req); err != nil {
+			return err
+		}
+	}
 
 	// Send request
 	resp, err := client.Do(req)
@@ -18,25 +35,51 @@ func do(req *http.Request, resType interface{}, returnHeaders bool) error {
 	}
 	defer resp.Body.Close()
 
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), p.now())
+
 	// Only 500 errors will not respond a readable result
 	if resp.StatusCode >= http.StatusInternalServerError {
-		return ErrHTTPPushover
+		httpErr := &HTTPError{StatusCode: resp.StatusCode, RetryAfter: retryAfter}
+		if retryAfter > 0 {
+			return &RateLimitError{RetryAfter: retryAfter, Err: httpErr}
+		}
+		return httpErr
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	// An empty body is not a malformed JSON, proxies might return a 204
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ErrEmptyResponse
 	}
 
 	// Decode the JSON response
-	if err := json.NewDecoder(resp.Body).Decode(&resType); err != nil {
+	if err := json.Unmarshal(body, &resType); err != nil {
 		return err
 	}
 
 	// Check if the unmarshaled data is a response
-	r, ok := resType.(*Response)
+	res, ok := resType.(responder)
 	if !ok {
 		return nil
 	}
+	r := res.response()
 
 	// Check response status
-	if r.Status != 1 {
-		return r.Errors
+	if !p.successStatus(r.Status) {
+		apiErr := &APIError{RequestID: r.ID, StatusCode: resp.StatusCode, Errors: r.Errors, Invalid: invalidParams(body)}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return &RateLimitError{RetryAfter: retryAfter, Err: apiErr}
+		}
+		return apiErr
+	}
+
+	// Check the shape of the request ID
+	if p.responseIDCheck() && !requestIDRegexp.MatchString(r.ID) {
+		return ErrMalformedResponse
 	}
 
 	// The headers are only returned when posting a new notification
@@ -0,0 +1,304 @@
+package pushover
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRetryAfter tests the Retry-After header is returned with the errors
+func TestRetryAfter(t *testing.T) {
+	tt := []struct {
+		name        string
+		status      int
+		retryAfter  string
+		body        string
+		expectedErr error
+	}{
+		{
+			name:        "server error",
+			status:      http.StatusInternalServerError,
+			expectedErr: &HTTPError{StatusCode: http.StatusInternalServerError},
+		},
+		{
+			name:       "unavailable with retry after",
+			status:     http.StatusServiceUnavailable,
+			retryAfter: "120",
+			expectedErr: &RateLimitError{
+				RetryAfter: 2 * time.Minute,
+				Err:        &HTTPError{StatusCode: http.StatusServiceUnavailable, RetryAfter: 2 * time.Minute},
+			},
+		},
+		{
+			name:        "too many requests",
+			status:      http.StatusTooManyRequests,
+			retryAfter:  "30",
+			body:        `{"status":0,"request":"e460545a8b333d0da2f3602aff3133d6","errors":["message limit reached"]}`,
+			expectedErr: &RateLimitError{RetryAfter: 30 * time.Second, Err: &APIError{RequestID: "e460545a8b333d0da2f3602aff3133d6", StatusCode: http.StatusTooManyRequests, Errors: Errors{"message limit reached"}}},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.retryAfter != "" {
+					w.Header().Set("Retry-After", tc.retryAfter)
+				}
+				w.WriteHeader(tc.status)
+				fmt.Fprintln(w, tc.body)
+			}))
+			defer ts.Close()
+
+			req, err := http.NewRequest("POST", ts.URL, nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			err = fakePushover.do(req, &Response{}, true)
+			if !reflect.DeepEqual(err, tc.expectedErr) {
+				t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if !errors.Is(err, ErrHTTPPushover) && tc.status >= http.StatusInternalServerError {
+				t.Errorf("expected the error to match ErrHTTPPushover")
+			}
+		})
+	}
+}
+
+// TestParseRetryAfter tests the parsing of the Retry-After header
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Unix(1393653600, 0)
+	if got := parseRetryAfter("10", now); got != 10*time.Second {
+		t.Errorf("expected 10s, got %s", got)
+	}
+
+	if got := parseRetryAfter("invalid", now); got != 0 {
+		t.Errorf("expected 0, got %s", got)
+	}
+
+	date := now.Add(time.Hour).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(date, now); got != time.Hour {
+		t.Errorf("expected 1h, got %s", got)
+	}
+
+	if got := parseRetryAfter(date, now.Add(2*time.Hour)); got != 0 {
+		t.Errorf("expected 0 for a past date, got %s", got)
+	}
+}
+
+// TestResponseIDCheck tests the check of the request ID of the responses
+func TestResponseIDCheck(t *testing.T) {
+	tt := []struct {
+		name        string
+		body        string
+		check       bool
+		expectedErr error
+	}{
+		{"valid ID", `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`, true, nil},
+		{"missing ID", `{"status":1}`, true, ErrMalformedResponse},
+		{"truncated ID", `{"status":1,"request":"e460545a8b333d"}`, true, ErrMalformedResponse},
+		{"unchecked ID", `{"status":1}`, false, nil},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, tc.body)
+			}))
+			defer ts.Close()
+
+			app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG")
+			app.SetResponseIDCheck(tc.check)
+
+			req, err := http.NewRequest("POST", ts.URL, nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			if err := app.do(req, &Response{}, false); err != tc.expectedErr {
+				t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+// TestEmptyResponse tests an empty body is distinguished from a malformed one
+func TestEmptyResponse(t *testing.T) {
+	tt := []struct {
+		name   string
+		status int
+		body   string
+		empty  bool
+	}{
+		{"no content", http.StatusNoContent, "", true},
+		{"empty body", http.StatusOK, "", true},
+		{"blank body", http.StatusOK, "\n", true},
+		{"malformed body", http.StatusOK, "{", false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				fmt.Fprint(w, tc.body)
+			}))
+			defer ts.Close()
+
+			req, err := http.NewRequest("POST", ts.URL, nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			err = fakePushover.do(req, &Response{}, false)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			if got := err == ErrEmptyResponse; got != tc.empty {
+				t.Fatalf("expected empty response %t, got %v", tc.empty, err)
+			}
+		})
+	}
+}
+
+// TestSuccessStatuses tests the status values considered as a success
+func TestSuccessStatuses(t *testing.T) {
+	tt := []struct {
+		name        string
+		statuses    []int
+		status      int
+		expectedErr error
+	}{
+		{"default success", nil, 1, nil},
+		{"default error", nil, 2, &APIError{RequestID: "e460545a8b333d0da2f3602aff3133d6", StatusCode: http.StatusOK, Errors: Errors{"queued"}}},
+		{"custom success", []int{1, 2}, 2, nil},
+		{"custom error", []int{2}, 1, &APIError{RequestID: "e460545a8b333d0da2f3602aff3133d6", StatusCode: http.StatusOK, Errors: Errors{"queued"}}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"status":%d,"request":"e460545a8b333d0da2f3602aff3133d6","errors":["queued"]}`, tc.status)
+			}))
+			defer ts.Close()
+
+			app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG")
+			app.SetSuccessStatuses(tc.statuses...)
+
+			req, err := http.NewRequest("POST", ts.URL, nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			err = app.do(req, &Response{}, false)
+			if !reflect.DeepEqual(err, tc.expectedErr) {
+				t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+// TestRequestInterceptor tests the requests go through the interceptor
+func TestRequestInterceptor(t *testing.T) {
+	var header string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("X-Trace-Id")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG")
+	app.SetRequestInterceptor(func(req *http.Request) error {
+		req.Header.Set("X-Trace-Id", "trace")
+		return nil
+	})
+
+	req, err := http.NewRequest("POST", ts.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+
+	if err := app.do(req, &Response{}, false); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if header != "trace" {
+		t.Errorf("expected the header to be set, got %q", header)
+	}
+
+	// The interceptor can abort the request
+	header = ""
+	errAbort := errors.New("abort")
+	app.SetRequestInterceptor(func(req *http.Request) error {
+		return errAbort
+	})
+
+	req, err = http.NewRequest("POST", ts.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+
+	if err := app.do(req, &Response{}, false); err != errAbort {
+		t.Fatalf("expected %v, got %v", errAbort, err)
+	}
+
+	if header != "" {
+		t.Error("expected the request not to be sent")
+	}
+}
+
+// TestUserAgent tests the User-Agent header of the requests
+func TestUserAgent(t *testing.T) {
+	var userAgent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	tt := []struct {
+		name     string
+		opts     []Option
+		expected string
+	}{
+		{"default", nil, DefaultUserAgent},
+		{"custom", []Option{WithUserAgent("my-app/1.0")}, "my-app/1.0"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			app := New(fakePushover.token, append(tc.opts, WithEndpoint(ts.URL))...)
+
+			if _, err := app.SendMessage(NewMessage("Hello"), fakeRecipient); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if userAgent != tc.expected {
+				t.Errorf("expected the user agent %q, got %q", tc.expected, userAgent)
+			}
+
+			message := NewMessage("Hello")
+			if err := message.AddAttachment(strings.NewReader("data")); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			userAgent = ""
+			if _, err := app.SendMessage(message, fakeRecipient); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if userAgent != tc.expected {
+				t.Errorf("expected the user agent %q with an attachment, got %q", tc.expected, userAgent)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package pushover
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // Response represents a response from the API.
 type Response struct {
@@ -9,10 +12,60 @@ type Response struct {
 	Errors  Errors `json:"errors"`
 	Receipt string `json:"receipt"`
 	Limit   *Limit
+
+	// Canceled is the number of emergency notifications canceled by
+	// Pushover.CancelEmergencyNotificationByTag.
+	Canceled int `json:"canceled"`
+
+	// Device is set to "invalid" by the API when the device name of the
+	// message is not one of the recipient devices.
+	Device string `json:"device"`
+
+	// DeviceFallback is true if the message was sent to all the devices of
+	// the recipient since its device was not found, see
+	// Pushover.SetDeviceFallback.
+	DeviceFallback bool `json:"-"`
+
+	// Recipient is the recipient who received the message, it's only set by
+	// SendWithFallback.
+	Recipient *Recipient `json:"-"`
+
+	// Skipped is true if a best effort message was not sent because the
+	// remaining quota is low.
+	Skipped bool `json:"-"`
+
+	// HTMLSent and MonospaceSent are the format flags sent with the message,
+	// after the per-recipient overrides.
+	HTMLSent      bool `json:"-"`
+	MonospaceSent bool `json:"-"`
+
+	// Coalesced is true if a glance update was not sent right away, see
+	// Pushover.SetGlanceCoalesce.
+	Coalesced bool `json:"-"`
+
+	// Attempts is the number of requests made to send the message and
+	// RetryElapsed the time elapsed between the first and the last one.
+	Attempts     int           `json:"-"`
+	RetryElapsed time.Duration `json:"-"`
+}
+
+// responder is implemented by the API responses, the types embedding a
+// Response get their status and errors checked by do.
+type responder interface {
+	response() *Response
+}
+
+// response returns the response itself.
+func (r *Response) response() *Response {
+	return r
 }
 
 // String represents a printable form of the response.
 func (r Response) String() string {
+	if r.Skipped {
+		return "Skipped: quota reserved\n"
+	}
+
 	ret := fmt.Sprintf("Status: %d\n", r.Status)
 	ret += fmt.Sprintf("Request id: %s\n", r.ID)
 	if r.Receipt != "" {
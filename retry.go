@@ -0,0 +1,71 @@
+package pushover
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// WithRetry retries the messages and the receipt details requests failing
+// with a server error, up to maxAttempts attempts in total. The delay between
+// the attempts starts at baseDelay and doubles after each attempt, with some
+// jitter. The Retry-After header of the server is honored if it asks for a
+// longer delay. There is no retry by default.
+func WithRetry(maxAttempts int, baseDelay time.Duration) Option {
+	return func(p *Pushover) {
+		p.retries = maxAttempts
+		p.retryDelay = baseDelay
+	}
+}
+
+// doRetry sends the requests returned by newRequest until one succeeds, the
+// error is not a server error or the attempts are exhausted, the last error
+// is returned. It returns the number of attempts and the time elapsed between
+// the first and the last one.
+func (p *Pushover) doRetry(ctx context.Context, newRequest func() (*http.Request, error), resType interface{}, returnHeaders bool) (int, time.Duration, error) {
+	clock := p.getClock()
+	start := clock.Now()
+
+	var elapsed time.Duration
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return attempt, elapsed, err
+		}
+
+		err = p.do(req.WithContext(ctx), resType, returnHeaders)
+		if err == nil || attempt >= p.retries || !errors.Is(err, ErrHTTPPushover) {
+			return attempt, elapsed, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, elapsed, ctx.Err()
+		case <-clock.After(p.retryBackoff(attempt, err)):
+		}
+		elapsed = clock.Now().Sub(start)
+	}
+}
+
+// retryBackoff returns the delay to wait after a failed attempt.
+func (p *Pushover) retryBackoff(attempt int, err error) time.Duration {
+	delay := p.retryDelay << uint(attempt-1)
+	if delay <= 0 {
+		// Overflow or no base delay
+		delay = p.retryDelay
+	}
+
+	// Wait between half and the full delay
+	if half := int64(delay / 2); half > 0 {
+		delay = time.Duration(half + rand.Int63n(half+1))
+	}
+
+	var rateErr *RateLimitError
+	if errors.As(err, &rateErr) && rateErr.RetryAfter > delay {
+		delay = rateErr.RetryAfter
+	}
+
+	return delay
+}
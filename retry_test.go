@@ -0,0 +1,70 @@
+package pushover
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestRetry tests the requests are retried on server errors
+func TestRetry(t *testing.T) {
+	tt := []struct {
+		name             string
+		failures         int
+		status           int
+		expectedRequests int
+		expectedErr      bool
+	}{
+		{"no failure", 0, http.StatusServiceUnavailable, 1, false},
+		{"recovered", 2, http.StatusServiceUnavailable, 3, false},
+		{"exhausted", 5, http.StatusServiceUnavailable, 3, true},
+		{"client error", 5, http.StatusBadRequest, 1, true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			requests := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests <= tc.failures {
+					w.WriteHeader(tc.status)
+					fmt.Fprintln(w, `{"status":0,"request":"e460545a8b333d0da2f3602aff3133d6","errors":["bad request"]}`)
+					return
+				}
+				w.Header().Set("X-Limit-App-Limit", "7500")
+				w.Header().Set("X-Limit-App-Remaining", "6000")
+				w.Header().Set("X-Limit-App-Reset", "1393653600")
+				fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+			}))
+			defer ts.Close()
+
+			clock := &fakeClock{now: time.Now()}
+			app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL), WithRetry(3, time.Second))
+			app.SetClock(clock)
+
+			response, err := app.SendMessage(NewMessage("Hello"), fakeRecipient)
+			if tc.expectedErr != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tc.expectedErr, err)
+			}
+
+			if requests != tc.expectedRequests {
+				t.Errorf("expected %d requests, got %d", tc.expectedRequests, requests)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if response.Attempts != tc.expectedRequests {
+				t.Errorf("expected %d attempts, got %d", tc.expectedRequests, response.Attempts)
+			}
+
+			// Between half and the full delays of 1s and 2s
+			if tc.failures > 0 && (response.RetryElapsed < 1500*time.Millisecond || response.RetryElapsed > 3*time.Second) {
+				t.Errorf("unexpected retry duration %s", response.RetryElapsed)
+			}
+		})
+	}
+}
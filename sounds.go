@@ -0,0 +1,35 @@
+package pushover
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// soundsResponse represents the response of the sounds endpoint.
+type soundsResponse struct {
+	Response
+	Sounds map[string]string `json:"sounds"`
+}
+
+// GetSounds returns the sounds available to the app, including the custom
+// sounds uploaded by the user, as a map of the sound identifiers to their
+// names.
+func (p *Pushover) GetSounds() (map[string]string, error) {
+	// Validate pushover
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/sounds.json?token=%s", p.Endpoint(), p.token)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &soundsResponse{}
+	if err := p.do(req, response, false); err != nil {
+		return nil, err
+	}
+
+	return response.Sounds, nil
+}
@@ -0,0 +1,39 @@
+package pushover
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// TestGetSounds tests the sounds are fetched from the API
+func TestGetSounds(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sounds.json" || r.URL.Query().Get("token") != fakePushover.token {
+			fmt.Fprintln(w, `{"status":0,"request":"e460545a8b333d0da2f3602aff3133d6","errors":["application token is invalid"]}`)
+			return
+		}
+		fmt.Fprintln(w, `{"sounds":{"pushover":"Pushover (default)","my_sound":"My sound"},"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+
+	app := New(fakePushover.token, WithEndpoint(ts.URL))
+	got, err := app.GetSounds()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := map[string]string{
+		"pushover": "Pushover (default)",
+		"my_sound": "My sound",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	if _, err := New("invalid").GetSounds(); err != ErrInvalidToken {
+		t.Errorf("expected %v, got %v", ErrInvalidToken, err)
+	}
+}
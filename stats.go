@@ -0,0 +1,46 @@
+package pushover
+
+import "sync/atomic"
+
+// Stats represents the activity of an app since its creation.
+type Stats struct {
+	// Sent is the number of messages sent.
+	Sent int64
+	// Failures is the number of messages that failed to be sent.
+	Failures int64
+	// AttachmentBytes is the total size of the attachments uploaded.
+	AttachmentBytes int64
+}
+
+// stats holds the counters of an app, they are updated atomically.
+type stats struct {
+	sent            int64
+	failures        int64
+	attachmentBytes int64
+}
+
+// record counts the result of a message send, the skipped messages are not
+// counted.
+func (s *stats) record(response *Response, err error) {
+	switch {
+	case err != nil:
+		atomic.AddInt64(&s.failures, 1)
+	case response != nil && !response.Skipped:
+		atomic.AddInt64(&s.sent, 1)
+	}
+}
+
+// addAttachmentBytes counts the size of an uploaded attachment.
+func (s *stats) addAttachmentBytes(n int) {
+	atomic.AddInt64(&s.attachmentBytes, int64(n))
+}
+
+// Stats returns the number of messages sent, the failures and the size of the
+// attachments uploaded by the app since its creation.
+func (p *Pushover) Stats() Stats {
+	return Stats{
+		Sent:            atomic.LoadInt64(&p.stats.sent),
+		Failures:        atomic.LoadInt64(&p.stats.failures),
+		AttachmentBytes: atomic.LoadInt64(&p.stats.attachmentBytes),
+	}
+}
@@ -0,0 +1,42 @@
+package pushover
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestStats tests the counters of the app
+func TestStats(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Limit-App-Limit", "7500")
+		w.Header().Set("X-Limit-App-Remaining", "6000")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		fmt.Fprintln(w, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	}))
+	defer ts.Close()
+	app := New("uQiRzpo4DXghDmr9QzzfQu27cmVRsG", WithEndpoint(ts.URL))
+
+	if _, err := app.SendMessage(NewMessage("Hello"), fakeRecipient); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	message := NewMessage("Hello")
+	if err := message.AddAttachment(strings.NewReader("data")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := app.SendMessage(message, fakeRecipient); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := app.SendMessage(NewMessage(""), fakeRecipient); err != ErrMessageEmpty {
+		t.Fatalf("expected %v, got %v", ErrMessageEmpty, err)
+	}
+
+	expected := Stats{Sent: 2, Failures: 1, AttachmentBytes: 4}
+	if got := app.Stats(); got != expected {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}